@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const configFileName = "config.json"
+
+// nomctlConfig holds the settings of long running commands. It is read from
+// config.json in the nomctl directory, a missing file is an empty config.
+//
+//	{
+//	  "exporter": {
+//	    "interval": "30s",
+//	    "addresses": ["z1...", "@label"],
+//	    "pillars": ["Local"]
+//	  },
+//	  "pillarMonitor": {
+//	    "exec": "notify-send \"$NOMCTL_ALERT_MESSAGE\"",
+//	    "webhook": "https://example.com/alerts",
+//	    "log": "/var/log/pillar-alerts.log",
+//	    "minWeightChange": 1
+//	  }
+//	}
+type nomctlConfig struct {
+	Exporter      exporterConfig      `json:"exporter"`
+	PillarMonitor pillarMonitorConfig `json:"pillarMonitor"`
+}
+
+func configPath() string {
+	return filepath.Join(nomctlDir, configFileName)
+}
+
+func readConfig(path string) (*nomctlConfig, error) {
+	config := new(nomctlConfig)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return config, nil
+}
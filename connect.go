@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypercore-one/go-zdk/client"
+	"github.com/hypercore-one/go-zdk/zdk"
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/rpc/api"
+	rpc "github.com/zenon-network/go-zenon/rpc/server"
+)
+
+const (
+	defaultDialTimeout    = 5 * time.Second
+	defaultRequestTimeout = 30 * time.Second
+	defaultConnectRetries = 3
+	connectBackoff        = 500 * time.Millisecond
+
+	// accountBlockVersion is the only account block version the node accepts,
+	// it is unrelated to the version of the momentums. No rpc of the node
+	// reports it, so sendBlock checks only the chain identifier and the node
+	// rejects a block of another version when it is published.
+	accountBlockVersion = 1
+
+	// chainIdAuto is the chainId of --chainId auto, the network of the node
+	// is adopted
+	chainIdAuto = 0
+)
+
+var (
+	dialTimeout    = defaultDialTimeout
+	requestTimeout = defaultRequestTimeout
+	connectRetries = defaultConnectRetries
+)
+
+// nodeEndpoints splits a comma separated --url into its endpoints
+func nodeEndpoints(url string) []string {
+	var endpoints []string
+	for _, endpoint := range strings.Split(url, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// nodeNetwork identifies the network of a node by its frontier momentum
+type nodeNetwork struct {
+	chainId uint64
+	version uint64
+}
+
+func (n nodeNetwork) String() string {
+	return fmt.Sprintf("chain %d momentum version %d", n.chainId, n.version)
+}
+
+// nodeClient is a client.Client over a list of endpoints. It uses the first
+// healthy one and fails over to the next when a request can't reach it.
+// Every endpoint has to report the network of the first one it connected to.
+type nodeClient struct {
+	endpoints       []string
+	retries         int
+	chainIdentifier uint64
+	protocolVersion uint64
+	zToken          types.ZenonTokenStandard
+	qToken          types.ZenonTokenStandard
+
+	mu       sync.Mutex
+	rpc      *rpc.Client
+	endpoint int
+	network  *nodeNetwork
+}
+
+func (c *nodeClient) call(r *rpc.Client, result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	return r.CallContext(ctx, result, method, args...)
+}
+
+// dial connects to an endpoint and checks that it serves the network
+func (c *nodeClient) dial(endpoint string) (*rpc.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	r, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	var frontier api.Momentum
+	if err := c.call(r, &frontier, "ledger.getFrontierMomentum"); err != nil {
+		r.Close()
+		return nil, err
+	}
+	if frontier.Momentum == nil {
+		r.Close()
+		return nil, errors.New("no frontier momentum")
+	}
+	network := nodeNetwork{chainId: frontier.ChainIdentifier, version: frontier.Version}
+	if c.network != nil && *c.network != network {
+		r.Close()
+		return nil, fmt.Errorf("%v does not match %v of the other endpoints", network, *c.network)
+	}
+	if c.network == nil && c.chainIdentifier == chainIdAuto {
+		c.chainIdentifier = network.chainId
+	}
+	if hyperqube && c.network == nil {
+		var meta api.LedgerMetaResponse
+		if err := c.call(r, &meta, "ledger.meta"); err != nil {
+			r.Close()
+			return nil, err
+		}
+		c.chainIdentifier, c.zToken, c.qToken = meta.ChainId, meta.ZToken, meta.QToken
+	}
+	c.network = &network
+	return r, nil
+}
+
+// current returns the connection, dialing the endpoints in turn with a
+// growing backoff until one is healthy
+func (c *nodeClient) current() (*rpc.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rpc != nil {
+		return c.rpc, nil
+	}
+	var errs []error
+	backoff := connectBackoff
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		for range c.endpoints {
+			endpoint := c.endpoints[c.endpoint]
+			r, err := c.dial(endpoint)
+			if err == nil {
+				c.rpc = r
+				return r, nil
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+			c.endpoint = (c.endpoint + 1) % len(c.endpoints)
+		}
+	}
+	if len(c.endpoints) == 1 {
+		return nil, errors.Unwrap(errs[len(errs)-1])
+	}
+	return nil, errors.Join(errs[len(errs)-len(c.endpoints):]...)
+}
+
+// drop closes a connection that failed so that the next request fails over
+func (c *nodeClient) drop(r *rpc.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rpc == r {
+		c.rpc.Close()
+		c.rpc = nil
+		c.endpoint = (c.endpoint + 1) % len(c.endpoints)
+	}
+}
+
+// close closes the connection, the next request dials again
+func (c *nodeClient) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rpc != nil {
+		c.rpc.Close()
+		c.rpc = nil
+	}
+}
+
+// nodeClientNoRetry are the methods that are never sent again after a
+// connection failure, a transaction could be published twice
+var nodeClientNoRetry = map[string]bool{
+	"ledger.publishRawTransaction": true,
+}
+
+// unsentRequest reports whether a request failed before it reached the node,
+// because the connection could not be reestablished or the request not
+// written. A timeout or a connection lost while waiting for the response
+// leaves open whether the node processed the request.
+func unsentRequest(err error) bool {
+	var opErr *net.OpError
+	return errors.Is(err, rpc.ErrClientQuit) || errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "write")
+}
+
+// retry reports whether a request is sent again. Errors returned by the node
+// keep the connection. Any other error drops it so that the next request
+// dials the next healthy endpoint, that is how callers that keep the client,
+// like exporter.run and pillar.monitor, reconnect. Only requests that did not
+// reach the node are sent again right away.
+func (c *nodeClient) retry(r *rpc.Client, err error, attempt int, method string) bool {
+	var rpcErr rpc.Error
+	if err == nil || errors.As(err, &rpcErr) || errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		return false
+	}
+	c.drop(r)
+	return unsentRequest(err) && !nodeClientNoRetry[method] && attempt < c.retries
+}
+
+func (c *nodeClient) Call(result interface{}, method string, args ...interface{}) error {
+	for attempt := 0; ; attempt++ {
+		r, err := c.current()
+		if err != nil {
+			return err
+		}
+		err = c.call(r, result, method, args...)
+		if !c.retry(r, err, attempt, method) {
+			return err
+		}
+	}
+}
+
+func (c *nodeClient) Subscribe(ctx context.Context, namespace string, channel interface{}, args ...interface{}) (client.Subscription, error) {
+	for attempt := 0; ; attempt++ {
+		r, err := c.current()
+		if err != nil {
+			return nil, err
+		}
+		sub, err := r.Subscribe(ctx, namespace, channel, args...)
+		if !c.retry(r, err, attempt, namespace) {
+			if err != nil {
+				return nil, err
+			}
+			return sub, nil
+		}
+	}
+}
+
+func (c *nodeClient) ProtocolVersion() uint64 {
+	return c.protocolVersion
+}
+
+// url is the endpoint of the connection
+func (c *nodeClient) url() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.endpoints[c.endpoint]
+}
+
+func (c *nodeClient) ChainIdentifier() uint64 {
+	return c.chainIdentifier
+}
+
+func (c *nodeClient) ZToken() types.ZenonTokenStandard {
+	return c.zToken
+}
+
+func (c *nodeClient) QToken() types.ZenonTokenStandard {
+	return c.qToken
+}
+
+func dialNode(url string, chainId int, retries int) (*zdk.Zdk, error) {
+	endpoints := nodeEndpoints(url)
+	if len(endpoints) == 0 {
+		return nil, errors.New("no url")
+	}
+	c := &nodeClient{
+		endpoints:       endpoints,
+		retries:         retries,
+		chainIdentifier: uint64(chainId),
+		protocolVersion: accountBlockVersion,
+		zToken:          types.ZnnTokenStandard,
+		qToken:          types.QsrTokenStandard,
+	}
+	if _, err := c.current(); err != nil {
+		return nil, err
+	}
+	return zdk.NewZdk(c), nil
+}
+
+// connect dials the first healthy endpoint of a comma separated url. With
+// --hyperqube the chain identifier and tokens are read from the node, with
+// --chainId auto the chain identifier.
+func connect(url string, chainId int) (*zdk.Zdk, error) {
+	return dialNode(url, chainId, connectRetries)
+}
+
+// connectOnce is connect without retries for callers that poll themselves
+func connectOnce(url string, chainId int) (*zdk.Zdk, error) {
+	return dialNode(url, chainId, 0)
+}
+
+// closeNode closes the connection of a client returned by connect, for
+// callers that probe a node repeatedly
+func closeNode(z *zdk.Zdk) {
+	if c, ok := z.Client.(*nodeClient); ok {
+		c.close()
+	}
+}
+
+// applyChainId parses --chainId, a number or auto
+func applyChainId(cCtx *cli.Context) error {
+	value := cCtx.String("chainId")
+	if value == "auto" {
+		chainId = chainIdAuto
+		return nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		fmt.Println("Error: chainId must be a positive number or auto")
+		return errors.New("invalid chainId " + value)
+	}
+	chainId = id
+	return nil
+}
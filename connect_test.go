@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/hypercore-one/go-zdk/zdk"
+	rpc "github.com/zenon-network/go-zenon/rpc/server"
+)
+
+func TestNodeEndpoints(t *testing.T) {
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"", nil},
+		{" , ,", nil},
+		{"ws://127.0.0.1:35998", []string{"ws://127.0.0.1:35998"}},
+		{"ws://a:1, ws://b:2 ,,ws://c:3", []string{"ws://a:1", "ws://b:2", "ws://c:3"}},
+	}
+	for _, tt := range tests {
+		if got := nodeEndpoints(tt.url); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("nodeEndpoints(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestUnsentRequest(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{rpc.ErrClientQuit, true},
+		{refused, true},
+		{fmt.Errorf("ws://a:1: %w", refused), true},
+		{&net.OpError{Op: "write", Net: "tcp", Err: syscall.EPIPE}, true},
+		{&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, false},
+		{io.EOF, false},
+		{context.DeadlineExceeded, false},
+		{errors.New("connection lost"), false},
+	}
+	for _, tt := range tests {
+		if got := unsentRequest(tt.err); got != tt.want {
+			t.Errorf("unsentRequest(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// nodeError is an error returned by the node
+type nodeError struct{}
+
+func (nodeError) Error() string  { return "invalid request" }
+func (nodeError) ErrorCode() int { return -32600 }
+
+func TestNodeClientRetry(t *testing.T) {
+	write := &net.OpError{Op: "write", Net: "tcp", Err: syscall.EPIPE}
+	tests := []struct {
+		err     error
+		attempt int
+		method  string
+		retry   bool
+		dropped bool
+	}{
+		{nil, 0, "ledger.getFrontierMomentum", false, false},
+		{nodeError{}, 0, "ledger.getFrontierMomentum", false, false},
+		{rpc.ErrNotificationsUnsupported, 0, "ledger", false, false},
+		{write, 0, "ledger.getFrontierMomentum", true, true},
+		{write, 2, "ledger.getFrontierMomentum", false, true},
+		{write, 0, "ledger.publishRawTransaction", false, true},
+		{context.DeadlineExceeded, 0, "ledger.getFrontierMomentum", false, true},
+		{io.EOF, 0, "ledger.publishRawTransaction", false, true},
+	}
+	for _, tt := range tests {
+		r := rpc.DialInProc(rpc.NewServer())
+		c := &nodeClient{endpoints: []string{"ws://a:1", "ws://b:2"}, retries: 2, rpc: r}
+		if got := c.retry(r, tt.err, tt.attempt, tt.method); got != tt.retry {
+			t.Errorf("retry(%v, %d, %s) = %v, want %v", tt.err, tt.attempt, tt.method, got, tt.retry)
+		}
+		// a dropped connection makes the next request dial the next endpoint
+		if dropped := c.rpc == nil; dropped != tt.dropped {
+			t.Errorf("retry(%v, %d, %s) dropped the connection: %v, want %v", tt.err, tt.attempt, tt.method, dropped, tt.dropped)
+		} else if dropped && c.url() != "ws://b:2" {
+			t.Errorf("retry(%v, %d, %s) fails over to %s, want ws://b:2", tt.err, tt.attempt, tt.method, c.url())
+		}
+		r.Close()
+	}
+}
+
+func TestCloseNode(t *testing.T) {
+	r := rpc.DialInProc(rpc.NewServer())
+	c := &nodeClient{endpoints: []string{"ws://a:1", "ws://b:2"}, rpc: r}
+	closeNode(zdk.NewZdk(c))
+	if c.rpc != nil {
+		t.Fatal("closeNode kept the connection")
+	}
+	if err := r.Call(nil, "ledger.getFrontierMomentum"); !errors.Is(err, rpc.ErrClientQuit) {
+		t.Errorf("call on the closed connection = %v, want %v", err, rpc.ErrClientQuit)
+	}
+	// closing is not a failure, the next request dials the same endpoint
+	if c.url() != "ws://a:1" {
+		t.Errorf("closeNode moved to %s, want ws://a:1", c.url())
+	}
+	closeNode(zdk.NewZdk(c))
+}
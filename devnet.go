@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+var devnetSecrets = &cli.Command{
+	Name:  "secrets",
+	Usage: "dataPath [passphrase]",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 && cCtx.NArg() != 2 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("secrets dataPath [passphrase]")
+			return nil
+		}
+
+		passphrase := cCtx.Args().Get(1)
+		if cCtx.NArg() == 1 {
+			var err error
+			passphrase, err = promptPassphrase("Insert the devnet secrets passphrase:")
+			if err != nil {
+				return err
+			}
+		}
+		secrets, err := readDevnetSecrets(cCtx.Args().Get(0), passphrase)
+		if err != nil {
+			fmt.Println("Error reading secrets:", err)
+			return err
+		}
+		data, err := json.MarshalIndent(secrets, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+var devnetSubcommands = []*cli.Command{
+	devnetGenesisReport,
+	devnetSecrets,
+	devnetStart,
+	devnetStop,
+	devnetStatus,
+	devnetLogs,
+	devnetReset,
+	devnetFaucetCommand,
+	devnetAddNode,
+}
+
+var devnetToolsCommand = cli.Command{
+	Name:        "devnet",
+	Usage:       "Tools for devnets created with generate-devnet",
+	Category:    "DEVELOPER COMMANDS",
+	Subcommands: devnetSubcommands,
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/node"
+	"github.com/zenon-network/go-zenon/p2p"
+	"github.com/zenon-network/go-zenon/wallet"
+)
+
+func readNodeConfig(dataPath string) (*node.Config, error) {
+	data, err := os.ReadFile(filepath.Join(dataPath, "config.json"))
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(node.Config)
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// enodeIP returns the ip of an enode url
+func enodeIP(enode string) (string, error) {
+	at := strings.LastIndex(enode, "@")
+	if at < 0 {
+		return "", errors.New("invalid enode " + enode)
+	}
+	host, _, err := net.SplitHostPort(enode[at+1:])
+	return host, err
+}
+
+// devnetRollback undoes the changes of add-node in reverse order
+type devnetRollback []func() error
+
+func (r *devnetRollback) add(undo func() error) {
+	*r = append(*r, undo)
+}
+
+func (r devnetRollback) run() {
+	for i := len(r) - 1; i >= 0; i-- {
+		_ = r[i]()
+	}
+}
+
+// save records how to restore path to its current content
+func (r *devnetRollback) save(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		r.add(func() error { return os.Remove(path) })
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	r.add(func() error { return os.WriteFile(path, data, info.Mode().Perm()) })
+	return nil
+}
+
+func (r *devnetRollback) writeJSONFile(path string, v interface{}, perm os.FileMode) error {
+	if err := r.save(path); err != nil {
+		return err
+	}
+	return writeJSONFile(path, v, perm)
+}
+
+func (r *devnetRollback) rename(from, to string) error {
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	r.add(func() error { return os.Rename(to, from) })
+	return nil
+}
+
+// moveSingleNode moves the node of a single node devnet, which uses the data
+// path itself, to its own directory so that added nodes are not created
+// inside of its data path. The devnet files stay in the data path.
+func moveSingleNode(r *devnetRollback, root string, n *devnetManifestNode) error {
+	if devnetProcessRunning(n.PID, n.DataPath) {
+		return fmt.Errorf("%s is running with pid %d, stop it first, it moves to %s", n.Name, n.PID, n.Name)
+	}
+	for _, emitted := range []string{devnetComposeFile, devnetSystemdDir} {
+		if _, err := os.Stat(filepath.Join(root, emitted)); err == nil {
+			return fmt.Errorf("%s moves to %s but %s uses its old data path, remove it first", n.Name, n.Name, emitted)
+		}
+	}
+	cfg, err := readNodeConfig(root)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(root, n.Name)
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return err
+	}
+	r.add(func() error { return os.Remove(dir) })
+
+	entries := append([]string{"config.json", p2p.DefaultNetPrivateKeyFile}, devnetStateEntries...)
+	walletPath := cfg.WalletPath
+	if wallet, err := filepath.Rel(root, walletPath); err == nil && insidePath(root, walletPath) && walletPath != root {
+		entries = append(entries, wallet)
+		cfg.WalletPath = filepath.Join(dir, wallet)
+	}
+	for _, entry := range entries {
+		if _, err := os.Lstat(filepath.Join(root, entry)); os.IsNotExist(err) {
+			continue
+		}
+		if err := r.rename(filepath.Join(root, entry), filepath.Join(dir, entry)); err != nil {
+			return err
+		}
+	}
+
+	// a keyStore records its own path, the node looks it up by that path
+	if cfg.WalletPath != walletPath {
+		files, err := os.ReadDir(cfg.WalletPath)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			path := filepath.Join(cfg.WalletPath, file.Name())
+			kf, err := wallet.ReadKeyFile(path)
+			if err != nil || kf.Path == path {
+				continue
+			}
+			kf.Path = path
+			data, err := json.MarshalIndent(kf, "", "    ")
+			if err != nil {
+				return err
+			}
+			if err := r.save(path); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				return err
+			}
+		}
+	}
+
+	cfg.DataPath = dir
+	if cfg.Producer != nil && cfg.WalletPath != walletPath {
+		if keyFile, err := filepath.Rel(walletPath, cfg.Producer.KeyFilePath); err == nil && insidePath(walletPath, cfg.Producer.KeyFilePath) {
+			cfg.Producer.KeyFilePath = filepath.Join(cfg.WalletPath, keyFile)
+		}
+	}
+	if err := r.writeJSONFile(filepath.Join(dir, "config.json"), cfg, 0600); err != nil {
+		return err
+	}
+	n.DataPath = dir
+	return nil
+}
+
+// addDevnetNode creates the next node-N of a devnet with the ports, genesis
+// and settings of the first node. It is wired to the seeders of the devnet
+// and the existing nodes get it as a seeder. A failure restores every file
+// that was changed.
+func addDevnetNode(dataPath string, options devnetNodeOptions, secretsPassphrase *string) (n *devnetNode, err error) {
+	manifest, err := readDevnetManifest(dataPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Nodes) == 0 {
+		return nil, errors.New("the devnet has no nodes")
+	}
+	seedersFile := filepath.Join(dataPath, devnetSeedersFile)
+	seeders, err := readDevnetSeeders(seedersFile)
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.Abs(dataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var rollback devnetRollback
+	defer func() {
+		if err != nil {
+			rollback.run()
+		}
+	}()
+	moved := len(manifest.Nodes) == 1 && filepath.Clean(manifest.Nodes[0].DataPath) == root
+	if moved {
+		if err = moveSingleNode(&rollback, root, &manifest.Nodes[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	first := manifest.Nodes[0]
+	base, err := readNodeConfig(first.DataPath)
+	if err != nil {
+		return nil, err
+	}
+	base.DataPath = root
+	base.GenesisFile = manifest.Genesis
+	base.Producer = nil
+	base.Net.ListenPort = first.P2PPort
+	base.RPC.HTTPPort = first.HTTPPort
+	base.RPC.WSPort = first.WSPort
+	if options.ip == "" {
+		if options.ip, err = enodeIP(first.Enode); err != nil {
+			return nil, err
+		}
+	}
+
+	options.first = len(manifest.Nodes)
+	options.count = 1
+	name := devnetNodeName(options.first)
+	nodeDir := filepath.Join(root, name)
+	if _, err = os.Stat(nodeDir); err == nil {
+		return nil, errors.New(name + " already exists")
+	}
+	offset := options.first * devnetPortOffset
+	for _, existing := range manifest.Nodes {
+		for _, port := range []int{existing.P2PPort, existing.HTTPPort, existing.WSPort} {
+			if port == base.Net.ListenPort+offset || port == base.RPC.HTTPPort+offset || port == base.RPC.WSPort+offset {
+				return nil, fmt.Errorf("port %d of %s is already used by %s", port, name, existing.Name)
+			}
+		}
+	}
+
+	rollback.add(func() error { return os.RemoveAll(nodeDir) })
+	nodes, err := createDevNodes(base, options)
+	if err != nil {
+		return nil, err
+	}
+	n = nodes[0]
+	// the pillars of a devnet are registered in its genesis
+	n.pillar = ""
+	n.cfg.Net.Seeders = append(n.cfg.Net.Seeders, seeders.Seeders...)
+	dialSeeders(&n.cfg)
+	if err = writeJSONFile(filepath.Join(n.cfg.DataPath, "config.json"), n.cfg, 0600); err != nil {
+		return nil, err
+	}
+
+	if secretsPassphrase != nil && n.cfg.Producer != nil {
+		secretsFile := filepath.Join(dataPath, devnetSecretsFile)
+		var secrets []devnetSecret
+		secrets, err = readDevnetSecrets(dataPath, *secretsPassphrase)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		secrets = append(secrets, devnetNodeSecrets(nodes)...)
+		if err = writeDevnetSecrets(secretsFile+".new", secrets, *secretsPassphrase); err != nil {
+			_ = os.Remove(secretsFile + ".new")
+			return nil, err
+		}
+		if err = rollback.save(secretsFile); err != nil {
+			return nil, err
+		}
+		if err = os.Rename(secretsFile+".new", secretsFile); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, existing := range manifest.Nodes {
+		var cfg *node.Config
+		if cfg, err = readNodeConfig(existing.DataPath); err != nil {
+			return nil, err
+		}
+		cfg.Net.Seeders = append(cfg.Net.Seeders, n.enode)
+		dialSeeders(cfg)
+		if err = rollback.writeJSONFile(filepath.Join(existing.DataPath, "config.json"), cfg, 0600); err != nil {
+			return nil, err
+		}
+	}
+	manifest.Nodes = append(manifest.Nodes, n.manifestNode())
+	if err = rollback.writeJSONFile(filepath.Join(dataPath, devnetManifestFile), manifest, 0644); err != nil {
+		return nil, err
+	}
+	seeders.Seeders = append(seeders.Seeders, n.enode)
+	if err = rollback.writeJSONFile(seedersFile, seeders, 0644); err != nil {
+		return nil, err
+	}
+	if moved {
+		fmt.Printf("Moved %s to %s\n", first.Name, first.DataPath)
+	}
+	return n, nil
+}
+
+var devnetAddNode = &cli.Command{
+	Name:  "add-node",
+	Usage: "[dataPath]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "producer",
+			Usage: "Create a producer keyStore, a pillar for it has to be registered on chain",
+		},
+		&cli.StringFlag{
+			Name:  "producer-password-file",
+			Usage: "File containing the producer password, defaults to a random password",
+		},
+		&cli.StringFlag{
+			Name:  "secrets-passphrase",
+			Usage: "Add the producer to the encrypted secrets.json of the devnet",
+		},
+		&cli.StringFlag{
+			Name:    "advertise-ip",
+			Aliases: []string{"listen-ip"},
+			Usage:   "IP address used in the seeder of the node, defaults to the one of the first node",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() > 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("add-node [--producer] [dataPath]")
+			return nil
+		}
+		options := devnetNodeOptions{
+			entropy: newDevnetEntropy(""),
+			ip:      cCtx.String("advertise-ip"),
+		}
+		if options.ip != "" && net.ParseIP(options.ip) == nil {
+			fmt.Println("Error: advertise-ip must be an IP address")
+			return errors.New("advertise-ip must be an IP address")
+		}
+		if cCtx.Bool("producer") {
+			options.pillars = 1
+			if cCtx.IsSet("producer-password-file") {
+				password, err := readProducerPassword(cCtx.String("producer-password-file"))
+				if err != nil {
+					fmt.Println("Error reading producer password:", err)
+					return err
+				}
+				options.password = password
+			}
+		}
+		var secretsPassphrase *string
+		if cCtx.IsSet("secrets-passphrase") {
+			passphrase := cCtx.String("secrets-passphrase")
+			secretsPassphrase = &passphrase
+		}
+
+		n, err := addDevnetNode(devnetDataPath(cCtx), options, secretsPassphrase)
+		if err != nil {
+			fmt.Println("Error adding node:", err)
+			return err
+		}
+		fmt.Printf("Added %s in %s\n", n.name, n.cfg.DataPath)
+		fmt.Println("Seeder", n.enode)
+		if n.cfg.Producer != nil {
+			fmt.Println("Producer", n.cfg.Producer.Address, "register a pillar with it to produce momentums")
+		}
+		fmt.Println("Restart running nodes to use the new seeder")
+		return nil
+	},
+}
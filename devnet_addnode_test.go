@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDevnetRollback(t *testing.T) {
+	dir := t.TempDir()
+	changed := filepath.Join(dir, "changed.json")
+	created := filepath.Join(dir, "created.json")
+	moved := filepath.Join(dir, "moved")
+	if err := os.WriteFile(changed, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(moved, []byte("moved"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var rollback devnetRollback
+	sub := filepath.Join(dir, "node-1")
+	if err := os.Mkdir(sub, 0700); err != nil {
+		t.Fatal(err)
+	}
+	rollback.add(func() error { return os.Remove(sub) })
+	if err := rollback.rename(moved, filepath.Join(sub, "moved")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rollback.writeJSONFile(changed, "new", 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := rollback.writeJSONFile(changed, "newer", 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := rollback.writeJSONFile(created, "new", 0644); err != nil {
+		t.Fatal(err)
+	}
+	rollback.run()
+
+	if data, err := os.ReadFile(changed); err != nil || string(data) != "old" {
+		t.Errorf("changed file = %q, %v, want %q", data, err, "old")
+	}
+	if data, err := os.ReadFile(moved); err != nil || string(data) != "moved" {
+		t.Errorf("moved file = %q, %v, want %q", data, err, "moved")
+	}
+	for _, path := range []string{created, sub} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s exists after the rollback", path)
+		}
+	}
+}
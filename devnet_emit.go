@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	devnetComposeFile = "docker-compose.yml"
+	devnetSystemdDir  = "systemd"
+	devnetEmitHeader  = "# generated by nomctl generate-devnet\n"
+)
+
+// devnetNodeBinary is the name of the node binary and docker image
+func devnetNodeBinary() string {
+	if hyperqube {
+		return "hqzd"
+	}
+	return "znnd"
+}
+
+type devnetComposeService struct {
+	Image   string   `yaml:"image"`
+	Command []string `yaml:"command"`
+	Restart string   `yaml:"restart"`
+	Ports   []string `yaml:"ports,omitempty"`
+	Volumes []string `yaml:"volumes"`
+}
+
+type devnetCompose struct {
+	Services map[string]devnetComposeService `yaml:"services"`
+}
+
+// insidePath reports whether path is dir or inside of it
+func insidePath(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// writeDevnetCompose writes a docker-compose.yml with a service per node. The
+// data path of every node is mounted at the same path so that the absolute
+// paths of config.json stay valid inside the container.
+func writeDevnetCompose(dataPath string, image string, nodes []*devnetNode) (string, error) {
+	compose := devnetCompose{Services: make(map[string]devnetComposeService)}
+	for _, n := range nodes {
+		service := devnetComposeService{
+			Image:   image,
+			Command: []string{"--data", n.cfg.DataPath},
+			Restart: "unless-stopped",
+			Ports: []string{
+				fmt.Sprintf("%d:%d", n.cfg.Net.ListenPort, n.cfg.Net.ListenPort),
+				fmt.Sprintf("%d:%d/udp", n.cfg.Net.ListenPort, n.cfg.Net.ListenPort),
+			},
+			Volumes: []string{n.cfg.DataPath + ":" + n.cfg.DataPath},
+		}
+		if n.cfg.RPC.EnableHTTP {
+			service.Ports = append(service.Ports, fmt.Sprintf("%d:%d", n.cfg.RPC.HTTPPort, n.cfg.RPC.HTTPPort))
+		}
+		if n.cfg.RPC.EnableWS {
+			service.Ports = append(service.Ports, fmt.Sprintf("%d:%d", n.cfg.RPC.WSPort, n.cfg.RPC.WSPort))
+		}
+		if !insidePath(n.cfg.DataPath, n.cfg.WalletPath) {
+			service.Volumes = append(service.Volumes, n.cfg.WalletPath+":"+n.cfg.WalletPath)
+		}
+		if !insidePath(n.cfg.DataPath, n.cfg.GenesisFile) {
+			service.Volumes = append(service.Volumes, n.cfg.GenesisFile+":"+n.cfg.GenesisFile+":ro")
+		}
+		compose.Services[n.name] = service
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(devnetEmitHeader)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(compose); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dataPath, devnetComposeFile)
+	return path, os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// systemdQuote quotes an ExecStart argument if it contains whitespace or quotes
+func systemdQuote(s string) string {
+	if strings.ContainsAny(s, " \t\n\"'\\") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// writeDevnetSystemd writes a <binary>-<node>.service unit per node to the
+// systemd directory of the data path
+func writeDevnetSystemd(dataPath string, binary string, chainId uint64, nodes []*devnetNode) ([]string, error) {
+	dir := filepath.Join(dataPath, devnetSystemdDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, n := range nodes {
+		var unit strings.Builder
+		unit.WriteString(devnetEmitHeader)
+		unit.WriteString("[Unit]\n")
+		fmt.Fprintf(&unit, "Description=Devnet %s of chain %d\n", n.name, chainId)
+		unit.WriteString("After=network-online.target\n")
+		unit.WriteString("Wants=network-online.target\n\n")
+		unit.WriteString("[Service]\n")
+		unit.WriteString("Type=simple\n")
+		fmt.Fprintf(&unit, "ExecStart=%s --data %s\n", systemdQuote(binary), systemdQuote(n.cfg.DataPath))
+		unit.WriteString("Restart=on-failure\n")
+		unit.WriteString("RestartSec=5\n")
+		unit.WriteString("LimitNOFILE=65536\n\n")
+		unit.WriteString("[Install]\n")
+		unit.WriteString("WantedBy=multi-user.target\n")
+
+		path := filepath.Join(dir, fmt.Sprintf("%s-%s.service", filepath.Base(binary), n.name))
+		if err := os.WriteFile(path, []byte(unit.String()), 0644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	signer "github.com/hypercore-one/go-zdk/wallet"
+	"github.com/hypercore-one/go-zdk/zdk"
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/node"
+	"github.com/zenon-network/go-zenon/vm/constants"
+	"github.com/zenon-network/go-zenon/wallet"
+)
+
+// devnetFaucet dispenses znn and qsr from one keyStore. Sends are serialized
+// because they all extend the account chain of the faucet.
+type devnetFaucet struct {
+	z        *zdk.Zdk
+	kp       signer.Signer
+	znn, qsr types.ZenonTokenStandard
+	// decimals of znn and qsr
+	znnDecimals, qsrDecimals uint8
+	// amounts in base units, a zero fuse amount disables fusing
+	znnAmount, qsrAmount, fuseAmount *big.Int
+	interval                         time.Duration
+
+	mu   sync.Mutex
+	last map[types.Address]time.Time
+}
+
+type devnetFaucetResponse struct {
+	Address string       `json:"address,omitempty"`
+	Znn     string       `json:"znn,omitempty"`
+	Qsr     string       `json:"qsr,omitempty"`
+	Fused   string       `json:"fused,omitempty"`
+	Hashes  []types.Hash `json:"hashes,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// devnetProducerSigner returns the signer of the first producer of a devnet
+// using the key file and password of its config.json
+func devnetProducerSigner(manifest *devnetManifest) (signer.Signer, error) {
+	for _, n := range manifest.Nodes {
+		if n.Producer == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(n.DataPath, "config.json"))
+		if err != nil {
+			return nil, err
+		}
+		cfg := new(node.Config)
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, err
+		}
+		if cfg.Producer == nil {
+			return nil, fmt.Errorf("%s has no producer in its config.json", n.Name)
+		}
+		kf, err := wallet.ReadKeyFile(cfg.Producer.KeyFilePath)
+		if err != nil {
+			return nil, err
+		}
+		ks, err := kf.Decrypt(cfg.Producer.Password)
+		if err != nil {
+			return nil, err
+		}
+		_, keyPair, err := ks.DeriveForIndexPath(cfg.Producer.Index)
+		if err != nil {
+			return nil, err
+		}
+		return signer.NewSigner(keyPair), nil
+	}
+	return nil, errors.New("the devnet has no producer, use --keyStore")
+}
+
+// faucetSigner returns the signer of --keyStore or of the devnet producer.
+// --keyStore is resolved like in the znn-cli commands.
+func faucetSigner(cCtx *cli.Context, manifest *devnetManifest) (signer.Signer, error) {
+	if !cCtx.IsSet("keyStore") {
+		return devnetProducerSigner(manifest)
+	}
+	return getZnnCliSigner(walletDir, cCtx)
+}
+
+// reserve records a request of address unless it is rate limited
+func (f *devnetFaucet) reserve(address types.Address, now time.Time) (time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if last, ok := f.last[address]; ok && now.Sub(last) < f.interval {
+		return f.interval - now.Sub(last), false
+	}
+	f.last[address] = now
+	return 0, true
+}
+
+func (f *devnetFaucet) release(address types.Address) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.last, address)
+}
+
+// dispense sends the configured amounts to address and fuses plasma for it
+// if it has none yet
+func (f *devnetFaucet) dispense(address types.Address) (*devnetFaucetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	response := &devnetFaucetResponse{Address: address.String()}
+	if f.fuseAmount.Sign() > 0 {
+		plasma, err := f.z.Embedded.Plasma.Get(address)
+		if err != nil {
+			return nil, err
+		}
+		if plasma.QsrAmount.Sign() == 0 {
+			tmpl, err := f.z.Embedded.Plasma.Fuse(address, f.fuseAmount)
+			if err != nil {
+				return nil, err
+			}
+			block, err := sendBlock(f.z, tmpl, f.kp)
+			if err != nil {
+				return nil, err
+			}
+			response.Fused = formatAmount(f.fuseAmount, f.qsrDecimals)
+			response.Hashes = append(response.Hashes, block.Hash)
+		}
+	}
+	for _, send := range []struct {
+		zts      types.ZenonTokenStandard
+		amount   *big.Int
+		decimals uint8
+		field    *string
+	}{
+		{f.znn, f.znnAmount, f.znnDecimals, &response.Znn},
+		{f.qsr, f.qsrAmount, f.qsrDecimals, &response.Qsr},
+	} {
+		if send.amount.Sign() == 0 {
+			continue
+		}
+		block, err := sendTokens(f.z, f.kp, address, send.zts, send.amount)
+		if err != nil {
+			return nil, err
+		}
+		*send.field = formatAmount(send.amount, send.decimals)
+		response.Hashes = append(response.Hashes, block.Hash)
+	}
+	return response, nil
+}
+
+// refill receives the funds sent to the faucet every interval
+func (f *devnetFaucet) refill(interval time.Duration) {
+	for {
+		f.mu.Lock()
+		received, err := receiveAll(f.z, f.kp)
+		f.mu.Unlock()
+		if err != nil {
+			log.Println("Error receiving txs:", err)
+		} else if received > 0 {
+			log.Println("Received", received, "transaction(s)")
+		}
+		time.Sleep(interval)
+	}
+}
+
+func writeFaucetResponse(w http.ResponseWriter, status int, response *devnetFaucetResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ServeHTTP handles GET or POST /?address=z1...
+func (f *devnetFaucet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeFaucetResponse(w, http.StatusMethodNotAllowed, &devnetFaucetResponse{Error: "use GET or POST"})
+		return
+	}
+	address, err := types.ParseAddress(r.FormValue("address"))
+	if err != nil {
+		writeFaucetResponse(w, http.StatusBadRequest, &devnetFaucetResponse{Error: "invalid address"})
+		return
+	}
+	if wait, ok := f.reserve(address, time.Now()); !ok {
+		w.Header().Set("Retry-After", fmt.Sprint(int(wait.Seconds())+1))
+		writeFaucetResponse(w, http.StatusTooManyRequests, &devnetFaucetResponse{
+			Address: address.String(),
+			Error:   fmt.Sprintf("try again in %v", wait.Round(time.Second)),
+		})
+		return
+	}
+	response, err := f.dispense(address)
+	if err != nil {
+		f.release(address)
+		log.Println("Error funding", address, err)
+		writeFaucetResponse(w, http.StatusInternalServerError, &devnetFaucetResponse{Address: address.String(), Error: err.Error()})
+		return
+	}
+	log.Println("Funded", address)
+	writeFaucetResponse(w, http.StatusOK, response)
+}
+
+var devnetFaucetCommand = &cli.Command{
+	Name:  "faucet",
+	Usage: "[dataPath]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Usage: "Address of the http server",
+			Value: "127.0.0.1:8090",
+		},
+		&cli.StringFlag{
+			Name:  "keyStore",
+			Usage: "Name of the faucet keyStore in the wallet directory, defaults to the devnet producer",
+		},
+		&cli.StringFlag{
+			Name:  "passphrase",
+			Usage: "Passphrase of --keyStore",
+		},
+		&cli.IntFlag{
+			Name:  "index",
+			Usage: "Address index of --keyStore",
+		},
+		&cli.StringFlag{
+			Name:  "znn",
+			Usage: "ZNN sent per request",
+			Value: "100",
+		},
+		&cli.StringFlag{
+			Name:  "qsr",
+			Usage: "QSR sent per request",
+			Value: "1000",
+		},
+		&cli.StringFlag{
+			Name:  "fuse",
+			Usage: "QSR fused for recipients without plasma, 0 disables fusing",
+			Value: "20",
+		},
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "Minimum time between two requests of an address",
+			Value: time.Hour,
+		},
+		&cli.DurationFlag{
+			Name:  "receive-interval",
+			Usage: "How often incoming funds are received",
+			Value: 10 * time.Second,
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() > 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("faucet [dataPath]")
+			return nil
+		}
+		manifest, err := readDevnetManifest(devnetDataPath(cCtx))
+		if err != nil {
+			fmt.Println("Error reading devnet manifest:", err)
+			return err
+		}
+		if len(manifest.Nodes) == 0 {
+			fmt.Println("Error: the devnet has no nodes")
+			return errors.New("the devnet has no nodes")
+		}
+		gen, err := readGenesisFile(manifest.Genesis)
+		if err != nil {
+			fmt.Println("Error reading genesis:", err)
+			return err
+		}
+
+		f := &devnetFaucet{
+			interval: cCtx.Duration("interval"),
+			last:     make(map[types.Address]time.Time),
+		}
+		f.znn, f.qsr = genesisNativeTokens(gen)
+		znnToken, qsrToken := findGenesisToken(gen, f.znn), findGenesisToken(gen, f.qsr)
+		if znnToken == nil || qsrToken == nil {
+			err := errors.New("the genesis has no znn and qsr tokens")
+			fmt.Println("Error:", err)
+			return err
+		}
+		f.znnDecimals, f.qsrDecimals = znnToken.Decimals, qsrToken.Decimals
+		for _, amount := range []struct {
+			flag     string
+			decimals uint8
+			value    **big.Int
+		}{
+			{"znn", f.znnDecimals, &f.znnAmount},
+			{"qsr", f.qsrDecimals, &f.qsrAmount},
+			{"fuse", f.qsrDecimals, &f.fuseAmount},
+		} {
+			if *amount.value, err = parseAmount(cCtx.String(amount.flag), amount.decimals); err != nil || (*amount.value).Sign() < 0 {
+				err = fmt.Errorf("invalid --%s amount %q", amount.flag, cCtx.String(amount.flag))
+				fmt.Println("Error:", err)
+				return err
+			}
+		}
+		if f.fuseAmount.Sign() > 0 && f.fuseAmount.Cmp(constants.FuseMinAmount) < 0 {
+			err := fmt.Errorf("minimum fusing amount is %v", formatAmount(constants.FuseMinAmount, f.qsrDecimals))
+			fmt.Println("Error:", err)
+			return err
+		}
+
+		f.kp, err = faucetSigner(cCtx, manifest)
+		if err != nil {
+			fmt.Println("Error getting signer:", err)
+			return err
+		}
+		f.z, err = connect(devnetEndpoints(manifest), int(manifest.ChainId))
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+
+		go f.refill(cCtx.Duration("receive-interval"))
+		fmt.Printf("Faucet %v listening on http://%s/?address=\n", f.kp.Address(), cCtx.String("listen"))
+		return http.ListenAndServe(cCtx.String("listen"), f)
+	},
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hypercore-one/go-zdk/zdk"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+const faucetTestAddress = "z1qzsz8w2u8tafzdfckvn0vylxhjcquz6mh8s3en"
+
+func newTestFaucet() *devnetFaucet {
+	return &devnetFaucet{
+		interval: time.Hour,
+		last:     make(map[types.Address]time.Time),
+	}
+}
+
+func TestFaucetReserve(t *testing.T) {
+	a := types.ParseAddressPanic(faucetTestAddress)
+	b := types.ParseAddressPanic("z1qxemdeddedxplasmaxxxxxxxxxxxxxxxxsctrp")
+	start := time.Unix(1700000000, 0)
+	tests := []struct {
+		address types.Address
+		after   time.Duration
+		ok      bool
+		wait    time.Duration
+	}{
+		{a, 0, true, 0},
+		{a, time.Minute, false, 59 * time.Minute},
+		{b, time.Minute, true, 0},
+		{a, time.Hour - time.Second, false, time.Second},
+		{a, time.Hour, true, 0},
+		{a, time.Hour + time.Minute, false, 59 * time.Minute},
+	}
+	f := newTestFaucet()
+	for i, tt := range tests {
+		wait, ok := f.reserve(tt.address, start.Add(tt.after))
+		if ok != tt.ok || wait != tt.wait {
+			t.Errorf("%d: reserve(%v, +%v) = %v, %v, want %v, %v", i, tt.address, tt.after, wait, ok, tt.wait, tt.ok)
+		}
+	}
+
+	f.release(a)
+	if _, ok := f.reserve(a, start.Add(time.Hour+2*time.Minute)); !ok {
+		t.Error("reserve after release is rate limited")
+	}
+}
+
+func TestFaucetServeHTTP(t *testing.T) {
+	tests := []struct {
+		method     string
+		target     string
+		reserved   bool
+		status     int
+		retryAfter string
+	}{
+		{http.MethodPut, "/?address=" + faucetTestAddress, false, http.StatusMethodNotAllowed, ""},
+		{http.MethodDelete, "/?address=" + faucetTestAddress, false, http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/", false, http.StatusBadRequest, ""},
+		{http.MethodGet, "/?address=z1qinvalid", false, http.StatusBadRequest, ""},
+		{http.MethodPost, "/?address=" + faucetTestAddress, true, http.StatusTooManyRequests, "3599"},
+		// no node is reachable, the dispense fails
+		{http.MethodGet, "/?address=" + faucetTestAddress, false, http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		f := newTestFaucet()
+		f.fuseAmount = big.NewInt(1)
+		f.z = zdk.NewZdk(&nodeClient{endpoints: []string{"ws://127.0.0.1:1"}})
+		if tt.reserved {
+			// 3598.5s are left, Retry-After rounds up
+			f.reserve(types.ParseAddressPanic(faucetTestAddress), time.Now().Add(-1500*time.Millisecond))
+		}
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.target, w.Code, tt.status)
+		}
+		if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+			t.Errorf("%s %s Retry-After = %q, want %q", tt.method, tt.target, got, tt.retryAfter)
+		}
+		// a failed dispense does not count against the rate limit
+		if tt.status == http.StatusInternalServerError && len(f.last) != 0 {
+			t.Errorf("%s %s kept the request after a failed dispense", tt.method, tt.target)
+		}
+	}
+}
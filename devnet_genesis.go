@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/chain/genesis"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+var errGenesisIncomplete = errors.New("genesis is missing a config section")
+
+var embeddedContractNames = map[types.Address]string{
+	types.PillarContract:      "pillar contract",
+	types.PlasmaContract:      "plasma contract",
+	types.StakeContract:       "stake contract",
+	types.SporkContract:       "spork contract",
+	types.TokenContract:       "token contract",
+	types.SentinelContract:    "sentinel contract",
+	types.SwapContract:        "swap contract",
+	types.LiquidityContract:   "liquidity contract",
+	types.AcceleratorContract: "accelerator contract",
+	types.HtlcContract:        "htlc contract",
+	types.BridgeContract:      "bridge contract",
+}
+
+// genesisNativeTokens returns the tokens used for pillar stakes and fusions.
+// These are ZNN and QSR, utilZ and utilQ or else the first two tokens.
+func genesisNativeTokens(gen *genesis.GenesisConfig) (types.ZenonTokenStandard, types.ZenonTokenStandard) {
+	for _, pair := range [][2]types.ZenonTokenStandard{
+		{types.ZnnTokenStandard, types.QsrTokenStandard},
+		{utilZ, utilQ},
+	} {
+		if findGenesisToken(gen, pair[0]) != nil && findGenesisToken(gen, pair[1]) != nil {
+			return pair[0], pair[1]
+		}
+	}
+	if len(gen.TokenConfig.Tokens) < 2 {
+		return types.ZeroTokenStandard, types.ZeroTokenStandard
+	}
+	return gen.TokenConfig.Tokens[0].TokenStandard, gen.TokenConfig.Tokens[1].TokenStandard
+}
+
+func genesisBalance(gen *genesis.GenesisConfig, address types.Address, zts types.ZenonTokenStandard) *big.Int {
+	total := big.NewInt(0)
+	for _, block := range gen.GenesisBlocks.Blocks {
+		if block.Address == address {
+			if balance, ok := block.BalanceList[zts]; ok {
+				total.Add(total, balance)
+			}
+		}
+	}
+	return total
+}
+
+// validateGenesisSupply checks the supply invariants of a genesis: contract
+// balances match the pillars, fusions and swap entries locked in them, the
+// balances of every token add up to its total supply which is at most its
+// max supply, and fusion ids are unique.
+func validateGenesisSupply(gen *genesis.GenesisConfig) error {
+	if gen.GenesisBlocks == nil || gen.TokenConfig == nil || gen.PillarConfig == nil || gen.PlasmaConfig == nil || gen.SwapConfig == nil {
+		return errGenesisIncomplete
+	}
+	var problems []error
+	znn, qsr := genesisNativeTokens(gen)
+
+	expect := func(name string, address types.Address, zts types.ZenonTokenStandard, expected *big.Int) {
+		if balance := genesisBalance(gen, address, zts); balance.Cmp(expected) != 0 {
+			problems = append(problems, fmt.Errorf("%s holds %v %v but %v are locked in it", name, balance, zts, expected))
+		}
+	}
+
+	pillars := big.NewInt(0)
+	for _, pillar := range gen.PillarConfig.Pillars {
+		pillars.Add(pillars, pillar.Amount)
+	}
+	if len(gen.PillarConfig.LegacyEntries) == 0 {
+		expect("pillar contract", types.PillarContract, znn, pillars)
+	}
+
+	fusions := big.NewInt(0)
+	ids := make(map[types.Hash]bool)
+	for _, fusion := range gen.PlasmaConfig.Fusions {
+		fusions.Add(fusions, fusion.Amount)
+		if ids[fusion.Id] {
+			problems = append(problems, fmt.Errorf("fusion id %v is not unique", fusion.Id))
+		}
+		ids[fusion.Id] = true
+	}
+	expect("plasma contract", types.PlasmaContract, qsr, fusions)
+
+	swapZnn, swapQsr := big.NewInt(0), big.NewInt(0)
+	for _, entry := range gen.SwapConfig.Entries {
+		swapZnn.Add(swapZnn, entry.Znn)
+		swapQsr.Add(swapQsr, entry.Qsr)
+	}
+	expect("swap contract", types.SwapContract, znn, swapZnn)
+	expect("swap contract", types.SwapContract, qsr, swapQsr)
+
+	balances := make(map[types.ZenonTokenStandard]*big.Int)
+	for _, block := range gen.GenesisBlocks.Blocks {
+		for zts, balance := range block.BalanceList {
+			if balance.Sign() < 0 {
+				problems = append(problems, fmt.Errorf("%v has a negative %v balance", block.Address, zts))
+			}
+			if _, ok := balances[zts]; !ok {
+				balances[zts] = big.NewInt(0)
+			}
+			balances[zts].Add(balances[zts], balance)
+		}
+	}
+	for _, token := range gen.TokenConfig.Tokens {
+		sum, ok := balances[token.TokenStandard]
+		if !ok {
+			sum = big.NewInt(0)
+		}
+		delete(balances, token.TokenStandard)
+		if sum.Cmp(token.TotalSupply) != 0 {
+			problems = append(problems, fmt.Errorf("%v balances add up to %v but the total supply is %v", token.TokenStandard, sum, token.TotalSupply))
+		}
+		if token.TotalSupply.Cmp(token.MaxSupply) > 0 {
+			problems = append(problems, fmt.Errorf("%v total supply %v exceeds the max supply %v", token.TokenStandard, token.TotalSupply, token.MaxSupply))
+		}
+	}
+	for zts := range balances {
+		problems = append(problems, fmt.Errorf("balances of %v but the token is not declared", zts))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid genesis supply:\n%w", errors.Join(problems...))
+	}
+	return nil
+}
+
+// genesisLabels names contracts, pillars and address book entries
+func genesisLabels(gen *genesis.GenesisConfig) map[types.Address]string {
+	labels := make(map[types.Address]string)
+	if book, err := readAddressBook(); err == nil {
+		for label, address := range book {
+			labels[address] = "@" + label
+		}
+	}
+	for _, pillar := range gen.PillarConfig.Pillars {
+		labels[pillar.StakeAddress] = "pillar " + pillar.Name
+	}
+	for address, name := range embeddedContractNames {
+		labels[address] = name
+	}
+	return labels
+}
+
+func printGenesisReport(gen *genesis.GenesisConfig) {
+	labels := genesisLabels(gen)
+	fmt.Printf("Chain identifier %d, genesis timestamp %d\n", gen.ChainIdentifier, gen.GenesisTimestampSec)
+	fmt.Printf("%d pillar(s), %d delegation(s), %d fusion(s)\n",
+		len(gen.PillarConfig.Pillars), len(gen.PillarConfig.Delegations), len(gen.PlasmaConfig.Fusions))
+
+	for _, token := range gen.TokenConfig.Tokens {
+		type holder struct {
+			address types.Address
+			amount  *big.Int
+		}
+		var holders []holder
+		for _, block := range gen.GenesisBlocks.Blocks {
+			if balance, ok := block.BalanceList[token.TokenStandard]; ok && balance.Sign() > 0 {
+				holders = append(holders, holder{block.Address, balance})
+			}
+		}
+		sort.SliceStable(holders, func(i, j int) bool {
+			return holders[i].amount.Cmp(holders[j].amount) > 0
+		})
+
+		fmt.Println()
+		fmt.Printf("%s (%s) %v\n", token.TokenName, token.TokenSymbol, token.TokenStandard)
+		fmt.Printf("Total supply %s of max %s\n",
+			formatAmount(token.TotalSupply, token.Decimals), formatAmount(token.MaxSupply, token.Decimals))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "Address\tLabel\tAmount\tShare\t")
+		for _, h := range holders {
+			share := 0.0
+			if token.TotalSupply.Sign() > 0 {
+				share, _ = new(big.Float).Quo(new(big.Float).SetInt(h.amount), new(big.Float).SetInt(token.TotalSupply)).Float64()
+			}
+			fmt.Fprintf(w, "%v\t%s\t%s\t%.2f%%\t\n", h.address, labels[h.address], formatAmount(h.amount, token.Decimals), share*100)
+		}
+		w.Flush()
+	}
+}
+
+// readGenesisFile reads a genesis.json or the genesis of a devnet data path
+func readGenesisFile(path string) (*genesis.GenesisConfig, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		if manifest, err := readDevnetManifest(path); err == nil {
+			path = manifest.Genesis
+		} else {
+			path = filepath.Join(path, "genesis.json")
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	gen := new(genesis.GenesisConfig)
+	if err := json.Unmarshal(data, gen); err != nil {
+		return nil, err
+	}
+	return gen, nil
+}
+
+var devnetGenesisReport = &cli.Command{
+	Name:  "genesis.report",
+	Usage: "genesisFile|dataPath",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("genesis.report genesisFile|dataPath")
+			return nil
+		}
+		gen, err := readGenesisFile(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error reading genesis:", err)
+			return err
+		}
+		if err := validateGenesisSupply(gen); err == errGenesisIncomplete {
+			fmt.Println("Error:", err)
+			return err
+		} else if err != nil {
+			fmt.Println(err)
+		} else {
+			fmt.Println("Genesis supply is consistent")
+		}
+		printGenesisReport(gen)
+		return nil
+	},
+}
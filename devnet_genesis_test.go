@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/zenon-network/go-zenon/chain/genesis"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+)
+
+var testGenesisProducer = types.PubKeyToAddress(make([]byte, 32))
+
+func testGenesis(t *testing.T) *genesis.GenesisConfig {
+	t.Helper()
+	gen, err := createDevGenesis(chainProfiles["nom"], &devnetSpec{}, []types.Address{testGenesisProducer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gen
+}
+
+// credit adds to the balance of an address and to the total supply of a
+// declared token
+func credit(gen *genesis.GenesisConfig, address types.Address, zts types.ZenonTokenStandard, amount int64) {
+	gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, &genesis.GenesisBlockConfig{
+		Address:     address,
+		BalanceList: map[types.ZenonTokenStandard]*big.Int{zts: big.NewInt(amount)},
+	})
+	if token := findGenesisToken(gen, zts); token != nil {
+		token.TotalSupply = new(big.Int).Add(token.TotalSupply, big.NewInt(amount))
+	}
+}
+
+func TestValidateGenesisSupply(t *testing.T) {
+	znn, qsr := types.ZnnTokenStandard, types.QsrTokenStandard
+	other := types.PubKeyToAddress([]byte{1})
+	tests := []struct {
+		name    string
+		change  func(gen *genesis.GenesisConfig)
+		problem string
+	}{
+		{"generated", func(gen *genesis.GenesisConfig) {}, ""},
+		{"balance", func(gen *genesis.GenesisConfig) {
+			credit(gen, other, znn, 100)
+			credit(gen, other, qsr, 100)
+		}, ""},
+		{"fusion", func(gen *genesis.GenesisConfig) {
+			gen.PlasmaConfig.Fusions = append(gen.PlasmaConfig.Fusions, &definition.FusionInfo{Owner: other, Id: types.NewHash([]byte{1}), Amount: big.NewInt(10)})
+			credit(gen, types.PlasmaContract, qsr, 10)
+		}, ""},
+		{"unlocked fusion", func(gen *genesis.GenesisConfig) {
+			gen.PlasmaConfig.Fusions = append(gen.PlasmaConfig.Fusions, &definition.FusionInfo{Owner: other, Id: types.NewHash([]byte{1}), Amount: big.NewInt(10)})
+		}, "plasma contract holds 0"},
+		{"duplicate fusion id", func(gen *genesis.GenesisConfig) {
+			for i := 0; i < 2; i++ {
+				gen.PlasmaConfig.Fusions = append(gen.PlasmaConfig.Fusions, &definition.FusionInfo{Owner: other, Id: types.NewHash([]byte{1}), Amount: big.NewInt(10)})
+			}
+			credit(gen, types.PlasmaContract, qsr, 20)
+		}, "is not unique"},
+		{"unlocked pillar", func(gen *genesis.GenesisConfig) {
+			gen.PillarConfig.Pillars = append(gen.PillarConfig.Pillars, &definition.PillarInfo{Name: "extra", Amount: big.NewInt(10)})
+		}, "pillar contract holds"},
+		{"unlocked swap entry", func(gen *genesis.GenesisConfig) {
+			gen.SwapConfig.Entries = append(gen.SwapConfig.Entries, &definition.SwapAssets{Znn: big.NewInt(5), Qsr: big.NewInt(0)})
+		}, "swap contract holds 0"},
+		{"total supply", func(gen *genesis.GenesisConfig) {
+			token := findGenesisToken(gen, znn)
+			token.TotalSupply = new(big.Int).Add(token.TotalSupply, big.NewInt(1))
+		}, "but the total supply is"},
+		{"max supply", func(gen *genesis.GenesisConfig) {
+			token := findGenesisToken(gen, qsr)
+			token.MaxSupply = new(big.Int).Sub(token.TotalSupply, big.NewInt(1))
+		}, "exceeds the max supply"},
+		{"negative balance", func(gen *genesis.GenesisConfig) {
+			credit(gen, other, znn, 10)
+			credit(gen, other, znn, -10)
+			credit(gen, other, znn, -10)
+			credit(gen, types.PubKeyToAddress([]byte{2}), znn, 10)
+		}, "negative"},
+		{"undeclared token", func(gen *genesis.GenesisConfig) {
+			credit(gen, other, utilZ, 10)
+		}, "is not declared"},
+	}
+	for _, tt := range tests {
+		gen := testGenesis(t)
+		tt.change(gen)
+		err := validateGenesisSupply(gen)
+		if tt.problem == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.problem) {
+			t.Errorf("%s: validateGenesisSupply = %v, want %q", tt.name, err, tt.problem)
+		}
+	}
+
+	gen := testGenesis(t)
+	gen.SwapConfig = nil
+	if err := validateGenesisSupply(gen); !errors.Is(err, errGenesisIncomplete) {
+		t.Errorf("incomplete: validateGenesisSupply = %v, want %v", err, errGenesisIncomplete)
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zenon-network/go-zenon/chain/genesis"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/node"
+	"github.com/zenon-network/go-zenon/p2p"
+	"github.com/zenon-network/go-zenon/p2p/discover"
+	"github.com/zenon-network/go-zenon/vm/constants"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+)
+
+const (
+	devnetManifestFile = "devnet.json"
+	devnetSeedersFile  = "seeders.json"
+	devnetSecretsFile  = "secrets.json"
+	devnetSecretsType  = "nomctl-devnet-secrets"
+
+	// node i listens on the default ports + i * devnetPortOffset
+	devnetPortOffset = 10
+)
+
+type devnetNode struct {
+	name     string
+	cfg      node.Config
+	pillar   string
+	enode    string
+	mnemonic string
+}
+
+type devnetNodeOptions struct {
+	pillars int
+	count   int
+	// first is the index of the first node, added nodes always get their own
+	// directory
+	first int
+
+	entropy  *devnetEntropy
+	password string
+	// ip is used in the enode urls
+	ip string
+}
+
+type devnetManifestNode struct {
+	Name     string `json:"name"`
+	DataPath string `json:"dataPath"`
+	Producer string `json:"producer,omitempty"`
+	Pillar   string `json:"pillar,omitempty"`
+	P2PPort  int    `json:"p2pPort"`
+	HTTPPort int    `json:"httpPort"`
+	WSPort   int    `json:"wsPort"`
+	Enode    string `json:"enode"`
+	// PID is the process started by devnet start
+	PID int `json:"pid,omitempty"`
+}
+
+// devnetManifest is written to the devnet data path and describes every node
+type devnetManifest struct {
+	ChainId uint64               `json:"chainId"`
+	Genesis string               `json:"genesis"`
+	Nodes   []devnetManifestNode `json:"nodes"`
+}
+
+func devnetNodeName(i int) string {
+	return fmt.Sprintf("node-%d", i+1)
+}
+
+func devnetPillarName(i int) string {
+	if i == 0 {
+		return "Local"
+	}
+	return fmt.Sprintf("Local-%d", i+1)
+}
+
+// createDevNodes creates a producer for the first pillars nodes and a p2p key
+// for every node from entropy. Producers use password or a random one each.
+// A single node uses the data path directly, otherwise every node gets its own
+// node-N directory and seeders pointing at the others.
+func createDevNodes(cfg *node.Config, options devnetNodeOptions) ([]*devnetNode, error) {
+	entropy := options.entropy
+	nodes := make([]*devnetNode, options.count)
+	for j := range nodes {
+		i := options.first + j
+		n := &devnetNode{name: devnetNodeName(i), cfg: *cfg}
+		if options.count > 1 || options.first > 0 {
+			n.cfg.DataPath = filepath.Join(cfg.DataPath, devnetNodeName(i))
+			n.cfg.WalletPath = filepath.Join(n.cfg.DataPath, node.DefaultWalletDir)
+		}
+		if err := os.MkdirAll(n.cfg.WalletPath, 0700); err != nil {
+			return nil, err
+		}
+		n.cfg.Net.ListenPort += i * devnetPortOffset
+		n.cfg.RPC.HTTPPort += i * devnetPortOffset
+		n.cfg.RPC.WSPort += i * devnetPortOffset
+
+		if j < options.pillars {
+			producerEntropy, err := entropy.read(fmt.Sprintf("producer/%d", i))
+			if err != nil {
+				return nil, err
+			}
+			producerPassword := options.password
+			if len(producerPassword) == 0 {
+				b, err := entropy.read(fmt.Sprintf("password/%d", i))
+				if err != nil {
+					return nil, err
+				}
+				producerPassword = base64.RawURLEncoding.EncodeToString(b[:24])
+			}
+			ks, err := createDevProducer(&n.cfg, producerEntropy, producerPassword)
+			if err != nil {
+				return nil, err
+			}
+			n.pillar = devnetPillarName(i)
+			n.mnemonic = ks.Mnemonic
+		}
+
+		key, err := entropy.networkKey(fmt.Sprintf("network/%d", i))
+		if err != nil {
+			return nil, err
+		}
+		if err := createDevNet(&n.cfg, key); err != nil {
+			return nil, err
+		}
+		id := discover.PubkeyID(&key.PublicKey)
+		n.enode = fmt.Sprintf("enode://%x@%s", id[:], net.JoinHostPort(options.ip, strconv.Itoa(n.cfg.Net.ListenPort)))
+		nodes[j] = n
+	}
+
+	if options.count > 1 {
+		for i, n := range nodes {
+			for j, other := range nodes {
+				if i != j {
+					n.cfg.Net.Seeders = append(n.cfg.Net.Seeders, other.enode)
+				}
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// dialSeeders makes a node with seeders connect to them and keeps a lone node
+// from dialing. The p2p server only dials new peers while it has less than
+// MinConnectedPeers.
+func dialSeeders(cfg *node.Config) {
+	if len(cfg.Net.Seeders) == 0 {
+		cfg.Net.MinConnectedPeers = 0
+	} else if cfg.Net.MinConnectedPeers == 0 {
+		cfg.Net.MinConnectedPeers = p2p.DefaultMinConnectedPeers
+	}
+}
+
+func devnetProducers(nodes []*devnetNode) []types.Address {
+	producers := make([]types.Address, 0, len(nodes))
+	for _, n := range nodes {
+		if n.cfg.Producer != nil {
+			a, _ := types.ParseAddress(n.cfg.Producer.Address)
+			producers = append(producers, a)
+		}
+	}
+	return producers
+}
+
+// addDevnetPillars registers a pillar for every producer. With more than one
+// pillar every producer also delegates a distinct amount to its own pillar so
+// that the pillars have different weights.
+func addDevnetPillars(gen *genesis.GenesisConfig, producers []types.Address) error {
+	znn := gen.TokenConfig.Tokens[0].TokenStandard
+	for i, producer := range producers {
+		gen.PillarConfig.Pillars = append(gen.PillarConfig.Pillars, &definition.PillarInfo{
+			Name:                         devnetPillarName(i),
+			Amount:                       new(big.Int).Set(constants.PillarStakeAmount),
+			BlockProducingAddress:        producer,
+			StakeAddress:                 producer,
+			RewardWithdrawAddress:        producer,
+			PillarType:                   1,
+			RevokeTime:                   0,
+			GiveBlockRewardPercentage:    0,
+			GiveDelegateRewardPercentage: 100,
+		})
+		if err := addGenesisBalance(gen, types.PillarContract, znn, constants.PillarStakeAmount); err != nil {
+			return err
+		}
+
+		if len(producers) > 1 {
+			weight := big.NewInt(int64(i+1) * 1000 * constants.Decimals)
+			if err := addGenesisBalance(gen, producer, znn, weight); err != nil {
+				return err
+			}
+			gen.PillarConfig.Delegations = append(gen.PillarConfig.Delegations, &definition.DelegationInfo{
+				Backer: producer,
+				Name:   devnetPillarName(i),
+			})
+		}
+	}
+	return nil
+}
+
+func readDevnetManifest(dataPath string) (*devnetManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dataPath, devnetManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	manifest := new(devnetManifest)
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func writeDevnetManifest(dataPath string, chainId uint64, genesisFile string, nodes []*devnetNode) error {
+	manifest := devnetManifest{
+		ChainId: chainId,
+		Genesis: genesisFile,
+		Nodes:   make([]devnetManifestNode, len(nodes)),
+	}
+	for i, n := range nodes {
+		manifest.Nodes[i] = n.manifestNode()
+	}
+	return manifest.write(dataPath)
+}
+
+func (n *devnetNode) manifestNode() devnetManifestNode {
+	entry := devnetManifestNode{
+		Name:     n.name,
+		DataPath: n.cfg.DataPath,
+		Pillar:   n.pillar,
+		P2PPort:  n.cfg.Net.ListenPort,
+		HTTPPort: n.cfg.RPC.HTTPPort,
+		WSPort:   n.cfg.RPC.WSPort,
+		Enode:    n.enode,
+	}
+	if n.cfg.Producer != nil {
+		entry.Producer = n.cfg.Producer.Address
+	}
+	return entry
+}
+
+func (m *devnetManifest) write(dataPath string) error {
+	return writeJSONFile(filepath.Join(dataPath, devnetManifestFile), m, 0644)
+}
+
+// devnetEndpoints is a comma separated url of the websocket endpoints of the
+// nodes of a devnet
+func devnetEndpoints(m *devnetManifest) string {
+	endpoints := make([]string, len(m.Nodes))
+	for i, n := range m.Nodes {
+		endpoints[i] = fmt.Sprintf("ws://127.0.0.1:%d", n.WSPort)
+	}
+	return strings.Join(endpoints, ",")
+}
+
+// devnetSeeders is shared with others to join a devnet with generate-devnet --join
+type devnetSeeders struct {
+	ChainId uint64          `json:"chainId"`
+	Seeders []string        `json:"seeders"`
+	Genesis json.RawMessage `json:"genesis"`
+}
+
+func readDevnetSeeders(path string) (*devnetSeeders, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	seeders := new(devnetSeeders)
+	if err := json.Unmarshal(data, seeders); err != nil {
+		return nil, err
+	}
+	if len(seeders.Seeders) == 0 || len(seeders.Genesis) == 0 {
+		return nil, errors.New(path + " has no seeders or genesis")
+	}
+	gen := new(genesis.GenesisConfig)
+	if err := json.Unmarshal(seeders.Genesis, gen); err != nil {
+		return nil, fmt.Errorf("invalid genesis in %s: %w", path, err)
+	}
+	if gen.ChainIdentifier != seeders.ChainId {
+		return nil, errors.New("chainId does not match the genesis in " + path)
+	}
+	return seeders, nil
+}
+
+// writeDevnetSeeders writes the enode urls of all nodes, including the ones
+// of a joined devnet
+func writeDevnetSeeders(dataPath string, chainId uint64, genesisData []byte, nodes []*devnetNode, join *devnetSeeders) ([]string, error) {
+	seeders := devnetSeeders{
+		ChainId: chainId,
+		Genesis: genesisData,
+	}
+	for _, n := range nodes {
+		seeders.Seeders = append(seeders.Seeders, n.enode)
+	}
+	if join != nil {
+		seeders.Seeders = append(seeders.Seeders, join.Seeders...)
+	}
+	if err := writeJSONFile(filepath.Join(dataPath, devnetSeedersFile), seeders, 0644); err != nil {
+		return nil, err
+	}
+	return seeders.Seeders, nil
+}
+
+type devnetSecret struct {
+	Node        string `json:"node"`
+	Address     string `json:"address"`
+	Mnemonic    string `json:"mnemonic"`
+	Password    string `json:"password"`
+	KeyFilePath string `json:"keyFilePath"`
+}
+
+func devnetNodeSecrets(nodes []*devnetNode) []devnetSecret {
+	secrets := make([]devnetSecret, 0, len(nodes))
+	for _, n := range nodes {
+		if n.cfg.Producer == nil {
+			continue
+		}
+		secrets = append(secrets, devnetSecret{
+			Node:        n.name,
+			Address:     n.cfg.Producer.Address,
+			Mnemonic:    n.mnemonic,
+			Password:    n.cfg.Producer.Password,
+			KeyFilePath: n.cfg.Producer.KeyFilePath,
+		})
+	}
+	return secrets
+}
+
+// writeDevnetSecrets stores the producer credentials encrypted with passphrase
+// in a new file
+func writeDevnetSecrets(path string, secrets []devnetSecret, passphrase string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	encrypted, err := encryptWithPassphrase(devnetSecretsType, plain, passphrase)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(encrypted, "", " ")
+	if err != nil {
+		return err
+	}
+	return writeFileExclusive(path, data, 0600)
+}
+
+func readDevnetSecrets(dataPath string, passphrase string) ([]devnetSecret, error) {
+	data, err := os.ReadFile(filepath.Join(dataPath, devnetSecretsFile))
+	if err != nil {
+		return nil, err
+	}
+	encrypted := new(encryptedData)
+	if err := json.Unmarshal(data, encrypted); err != nil {
+		return nil, err
+	}
+	if encrypted.Type != devnetSecretsType {
+		return nil, errors.New("not a devnet secrets file")
+	}
+	plain, err := encrypted.decrypt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var secrets []devnetSecret
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
@@ -0,0 +1,561 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/node"
+	"github.com/zenon-network/go-zenon/p2p"
+)
+
+// devnetProcessLog receives the stdout and stderr of a node started by
+// devnet start, the node itself logs to log/zenon.log
+const devnetProcessLog = "znnd.log"
+
+// devnetStateEntries hold the chain state of a node. devnet reset removes them
+// and keeps config.json, the network key, the wallet and the genesis.
+var devnetStateEntries = []string{"nom", "consensus", p2p.DefaultNetDirName, "log", ".lock", devnetProcessLog}
+
+var (
+	devnetNodeFlag = &cli.StringSliceFlag{
+		Name:  "node",
+		Usage: "Only use this node, e.g. node-2. Can be repeated, defaults to all nodes",
+	}
+	devnetBinaryFlag = &cli.StringFlag{
+		Name:    "binary",
+		Usage:   "Path of the node binary, defaults to znnd or hqzd with --hyperqube",
+		EnvVars: []string{"NOMCTL_ZNND"},
+	}
+	devnetWaitFlag = &cli.DurationFlag{
+		Name:  "wait",
+		Usage: "How long to wait for the rpc endpoint of every node, 0 does not wait",
+		Value: time.Minute,
+	}
+	devnetYesFlag = &cli.BoolFlag{
+		Name:  "yes",
+		Usage: "Do not ask for confirmation",
+	}
+	devnetTimeoutFlag = &cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "How long to wait for a node to shut down before killing it",
+		Value: 30 * time.Second,
+	}
+)
+
+// devnetDataPath returns the optional dataPath argument or the default
+func devnetDataPath(cCtx *cli.Context) string {
+	if cCtx.NArg() == 1 {
+		return cCtx.Args().Get(0)
+	}
+	return node.DefaultDataDir()
+}
+
+// selectDevnetNodes returns the indexes of the manifest nodes named by --node
+func selectDevnetNodes(manifest *devnetManifest, names []string) ([]int, error) {
+	if len(names) == 0 {
+		indexes := make([]int, len(manifest.Nodes))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+	var indexes []int
+	for _, name := range names {
+		found := false
+		for i, n := range manifest.Nodes {
+			if n.Name == name {
+				indexes = append(indexes, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("the devnet has no node %s", name)
+		}
+	}
+	return indexes, nil
+}
+
+// devnetProcessRunning reports whether pid is still the node of dataPath. The
+// pid of a node that exited can be reused by another process, where /proc is
+// available its command line has to contain --data dataPath.
+func devnetProcessRunning(pid int, dataPath string) bool {
+	if pid <= 0 {
+		return false
+	}
+	if cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid)); err == nil {
+		if !devnetNodeCommand(strings.Split(string(cmdline), "\x00"), dataPath) {
+			return false
+		}
+	} else if _, err := os.Stat("/proc/self"); err == nil {
+		return false
+	}
+	return devnetPidAlive(pid)
+}
+
+// devnetNodeCommand reports whether args are the ones of startDevnetNode
+func devnetNodeCommand(args []string, dataPath string) bool {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "--data" && args[i+1] == dataPath {
+			return true
+		}
+	}
+	return false
+}
+
+func devnetBinary(cCtx *cli.Context) (string, error) {
+	binary := cCtx.String(devnetBinaryFlag.Name)
+	if binary == "" {
+		binary = devnetNodeBinary()
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return "", fmt.Errorf("node binary not found, set it with --binary or NOMCTL_ZNND: %w", err)
+	}
+	return filepath.Abs(path)
+}
+
+// startDevnetNode starts binary for n in the background. exited is closed
+// when the process ends while nomctl is still running.
+func startDevnetNode(binary string, n devnetManifestNode) (pid int, exited <-chan struct{}, err error) {
+	logFile, err := os.OpenFile(filepath.Join(n.DataPath, devnetProcessLog), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(binary, "--data", n.DataPath)
+	cmd.Dir = n.DataPath
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// keep the node out of the process group of nomctl, Ctrl-C on devnet
+	// start --wait or devnet logs must not stop it
+	cmd.SysProcAttr = devnetSysProcAttr()
+	if err := cmd.Start(); err != nil {
+		return 0, nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+	return cmd.Process.Pid, done, nil
+}
+
+// waitDevnetNode polls the rpc endpoint of n until it returns the frontier
+// momentum, the process exits or timeout passes
+func waitDevnetNode(n devnetManifestNode, chainId uint64, exited <-chan struct{}, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if z, err := connectOnce(fmt.Sprintf("ws://127.0.0.1:%d", n.WSPort), int(chainId)); err == nil {
+			_, err := z.Ledger.GetFrontierMomentum()
+			closeNode(z)
+			if err == nil {
+				return nil
+			}
+		}
+		select {
+		case <-exited:
+			return fmt.Errorf("%s exited, see %s", n.Name, filepath.Join(n.DataPath, devnetProcessLog))
+		case <-time.After(time.Second):
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s rpc endpoint is not healthy after %v", n.Name, timeout)
+		}
+	}
+}
+
+// stopDevnetNode interrupts the node of dataPath and kills it after timeout
+func stopDevnetNode(pid int, dataPath string, timeout time.Duration) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		return process.Kill()
+	}
+	deadline := time.Now().Add(timeout)
+	for devnetProcessRunning(pid, dataPath) {
+		if time.Now().After(deadline) {
+			return process.Kill()
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return nil
+}
+
+var devnetStart = &cli.Command{
+	Name:  "start",
+	Usage: "[dataPath]",
+	Flags: []cli.Flag{devnetNodeFlag, devnetBinaryFlag, devnetWaitFlag},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() > 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("start [dataPath]")
+			return nil
+		}
+		dataPath := devnetDataPath(cCtx)
+		manifest, err := readDevnetManifest(dataPath)
+		if err != nil {
+			fmt.Println("Error reading devnet manifest:", err)
+			return err
+		}
+		indexes, err := selectDevnetNodes(manifest, cCtx.StringSlice(devnetNodeFlag.Name))
+		if err != nil {
+			fmt.Println("Error:", err)
+			return err
+		}
+		binary, err := devnetBinary(cCtx)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return err
+		}
+
+		exited := make(map[int]<-chan struct{})
+		for _, i := range indexes {
+			n := &manifest.Nodes[i]
+			if devnetProcessRunning(n.PID, n.DataPath) {
+				fmt.Printf("%s is already running with pid %d\n", n.Name, n.PID)
+				continue
+			}
+			pid, done, err := startDevnetNode(binary, *n)
+			if err != nil {
+				fmt.Printf("Error starting %s: %v\n", n.Name, err)
+				return err
+			}
+			n.PID = pid
+			exited[i] = done
+			if err := manifest.write(dataPath); err != nil {
+				fmt.Println("Error writing devnet manifest:", err)
+				return err
+			}
+			fmt.Printf("Started %s with pid %d\n", n.Name, pid)
+		}
+
+		timeout := cCtx.Duration(devnetWaitFlag.Name)
+		if timeout <= 0 {
+			return nil
+		}
+		for _, i := range indexes {
+			done, ok := exited[i]
+			if !ok {
+				continue
+			}
+			n := manifest.Nodes[i]
+			if err := waitDevnetNode(n, manifest.ChainId, done, timeout); err != nil {
+				fmt.Println("Error:", err)
+				return err
+			}
+			fmt.Printf("%s is serving ws://127.0.0.1:%d\n", n.Name, n.WSPort)
+		}
+		return nil
+	},
+}
+
+var devnetStop = &cli.Command{
+	Name:  "stop",
+	Usage: "[dataPath]",
+	Flags: []cli.Flag{devnetNodeFlag, devnetTimeoutFlag},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() > 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("stop [dataPath]")
+			return nil
+		}
+		dataPath := devnetDataPath(cCtx)
+		manifest, err := readDevnetManifest(dataPath)
+		if err != nil {
+			fmt.Println("Error reading devnet manifest:", err)
+			return err
+		}
+		indexes, err := selectDevnetNodes(manifest, cCtx.StringSlice(devnetNodeFlag.Name))
+		if err != nil {
+			fmt.Println("Error:", err)
+			return err
+		}
+
+		for _, i := range indexes {
+			n := &manifest.Nodes[i]
+			if !devnetProcessRunning(n.PID, n.DataPath) {
+				fmt.Printf("%s is not running\n", n.Name)
+			} else if err := stopDevnetNode(n.PID, n.DataPath, cCtx.Duration(devnetTimeoutFlag.Name)); err != nil {
+				fmt.Printf("Error stopping %s: %v\n", n.Name, err)
+				return err
+			} else {
+				fmt.Printf("Stopped %s\n", n.Name)
+			}
+			n.PID = 0
+		}
+		if err := manifest.write(dataPath); err != nil {
+			fmt.Println("Error writing devnet manifest:", err)
+			return err
+		}
+		return nil
+	},
+}
+
+var devnetStatus = &cli.Command{
+	Name:  "status",
+	Usage: "[dataPath]",
+	Flags: []cli.Flag{devnetNodeFlag},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() > 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("status [dataPath]")
+			return nil
+		}
+		manifest, err := readDevnetManifest(devnetDataPath(cCtx))
+		if err != nil {
+			fmt.Println("Error reading devnet manifest:", err)
+			return err
+		}
+		indexes, err := selectDevnetNodes(manifest, cCtx.StringSlice(devnetNodeFlag.Name))
+		if err != nil {
+			fmt.Println("Error:", err)
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Node\tPillar\tPID\tState\tHeight\tSync\tWS")
+		for _, i := range indexes {
+			n := manifest.Nodes[i]
+			pid, state, height, sync := "-", "stopped", "-", "-"
+			if devnetProcessRunning(n.PID, n.DataPath) {
+				pid, state = strconv.Itoa(n.PID), "running"
+				if z, err := connectOnce(fmt.Sprintf("ws://127.0.0.1:%d", n.WSPort), int(manifest.ChainId)); err != nil {
+					state = "running, rpc unavailable"
+				} else {
+					if m, err := z.Ledger.GetFrontierMomentum(); err == nil {
+						height = strconv.FormatUint(m.Height, 10)
+					}
+					if info, err := z.Stats.SyncInfo(); err == nil {
+						sync = syncStateNames[info.State]
+					}
+					closeNode(z)
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\n", n.Name, n.Pillar, pid, state, height, sync, n.WSPort)
+		}
+		return w.Flush()
+	},
+}
+
+// tailLines returns the last n lines of path and its size
+func tailLines(path string, n int) ([]string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// read chunks from the end until there are enough lines
+	const chunk = 32 * 1024
+	var data []byte
+	for offset := info.Size(); offset > 0 && strings.Count(string(data), "\n") <= n; {
+		size := int64(chunk)
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+		buf := make([]byte, size)
+		if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		data = append(buf, data...)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	if len(data) == 0 {
+		lines = nil
+	}
+	return lines, info.Size(), nil
+}
+
+// followLogs prints the lines appended to paths, prefixed with their name,
+// until nomctl is interrupted
+func followLogs(names []string, paths []string, offsets []int64) error {
+	prefix := len(names) > 1
+	for {
+		for i, path := range paths {
+			f, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			if info, err := f.Stat(); err == nil && info.Size() < offsets[i] {
+				// the log was rotated or reset
+				offsets[i] = 0
+			}
+			if _, err := f.Seek(offsets[i], io.SeekStart); err != nil {
+				f.Close()
+				return err
+			}
+			reader := bufio.NewReader(f)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					// keep a partial line for the next round
+					break
+				}
+				offsets[i] += int64(len(line))
+				if prefix {
+					fmt.Printf("%s | %s", names[i], line)
+				} else {
+					fmt.Print(line)
+				}
+			}
+			f.Close()
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+var devnetLogs = &cli.Command{
+	Name:  "logs",
+	Usage: "[dataPath]",
+	Flags: []cli.Flag{
+		devnetNodeFlag,
+		&cli.IntFlag{
+			Name:  "lines",
+			Usage: "Number of lines to print from the end of every log",
+			Value: 20,
+		},
+		&cli.BoolFlag{
+			Name:  "follow",
+			Usage: "Keep printing lines as they are appended",
+		},
+		&cli.BoolFlag{
+			Name:  "error",
+			Usage: "Print the error log instead of the node log",
+		},
+		&cli.BoolFlag{
+			Name:  "stdout",
+			Usage: "Print the stdout and stderr of the node process instead of the node log",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() > 1 || cCtx.Bool("error") && cCtx.Bool("stdout") {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("logs [--error|--stdout] [dataPath]")
+			return nil
+		}
+		manifest, err := readDevnetManifest(devnetDataPath(cCtx))
+		if err != nil {
+			fmt.Println("Error reading devnet manifest:", err)
+			return err
+		}
+		indexes, err := selectDevnetNodes(manifest, cCtx.StringSlice(devnetNodeFlag.Name))
+		if err != nil {
+			fmt.Println("Error:", err)
+			return err
+		}
+
+		names := make([]string, len(indexes))
+		paths := make([]string, len(indexes))
+		offsets := make([]int64, len(indexes))
+		for j, i := range indexes {
+			n := manifest.Nodes[i]
+			names[j] = n.Name
+			switch {
+			case cCtx.Bool("error"):
+				paths[j] = filepath.Join(n.DataPath, "log", "error", "zenon.error.log")
+			case cCtx.Bool("stdout"):
+				paths[j] = filepath.Join(n.DataPath, devnetProcessLog)
+			default:
+				paths[j] = filepath.Join(n.DataPath, "log", "zenon.log")
+			}
+
+			lines, size, err := tailLines(paths[j], cCtx.Int("lines"))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				fmt.Println("Error reading log:", err)
+				return err
+			}
+			offsets[j] = size
+			if len(indexes) > 1 && !cCtx.Bool("follow") {
+				fmt.Printf("==> %s <==\n", n.Name)
+			}
+			for _, line := range lines {
+				if len(indexes) > 1 && cCtx.Bool("follow") {
+					fmt.Printf("%s | %s\n", n.Name, line)
+				} else {
+					fmt.Println(line)
+				}
+			}
+		}
+		if cCtx.Bool("follow") {
+			return followLogs(names, paths, offsets)
+		}
+		return nil
+	},
+}
+
+var devnetReset = &cli.Command{
+	Name:  "reset",
+	Usage: "[dataPath]",
+	Flags: []cli.Flag{devnetNodeFlag, devnetYesFlag},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() > 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("reset [dataPath]")
+			return nil
+		}
+		manifest, err := readDevnetManifest(devnetDataPath(cCtx))
+		if err != nil {
+			fmt.Println("Error reading devnet manifest:", err)
+			return err
+		}
+		indexes, err := selectDevnetNodes(manifest, cCtx.StringSlice(devnetNodeFlag.Name))
+		if err != nil {
+			fmt.Println("Error:", err)
+			return err
+		}
+
+		for _, i := range indexes {
+			if n := manifest.Nodes[i]; devnetProcessRunning(n.PID, n.DataPath) {
+				err := fmt.Errorf("%s is running with pid %d, stop it first", n.Name, n.PID)
+				fmt.Println("Error:", err)
+				return err
+			}
+		}
+		if !cCtx.Bool(devnetYesFlag.Name) {
+			names := make([]string, len(indexes))
+			for j, i := range indexes {
+				names[j] = manifest.Nodes[i].Name
+			}
+			ok, err := confirm(fmt.Sprintf("Remove the chain state of %s?", strings.Join(names, ", ")))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Aborted")
+				return nil
+			}
+		}
+		for _, i := range indexes {
+			n := manifest.Nodes[i]
+			for _, entry := range devnetStateEntries {
+				if err := os.RemoveAll(filepath.Join(n.DataPath, entry)); err != nil {
+					fmt.Printf("Error resetting %s: %v\n", n.Name, err)
+					return err
+				}
+			}
+			fmt.Printf("Reset the chain state of %s\n", n.Name)
+		}
+		return nil
+	},
+}
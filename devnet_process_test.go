@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestDevnetNodeCommand(t *testing.T) {
+	tests := []struct {
+		args  []string
+		valid bool
+	}{
+		{[]string{"/usr/bin/znnd", "--data", "/tmp/devnet"}, true},
+		{[]string{"znnd", "--verbose", "--data", "/tmp/devnet", ""}, true},
+		{[]string{"znnd", "--data", "/tmp/devnet/node-2"}, false},
+		{[]string{"znnd", "--data"}, false},
+		{[]string{"znnd", "/tmp/devnet"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := devnetNodeCommand(tt.args, "/tmp/devnet"); got != tt.valid {
+			t.Errorf("devnetNodeCommand(%q) = %v, want %v", tt.args, got, tt.valid)
+		}
+	}
+}
+
+func TestDevnetProcessRunning(t *testing.T) {
+	if _, err := os.Stat("/proc/self/cmdline"); err != nil {
+		t.Skip("no /proc")
+	}
+	cmd := exec.Command("sh", "-c", "sleep 30; true", "sh", "--data", "/tmp/devnet")
+	if err := cmd.Start(); err != nil {
+		t.Skip(err)
+	}
+	pid := cmd.Process.Pid
+	defer cmd.Process.Kill()
+
+	if !devnetProcessRunning(pid, "/tmp/devnet") {
+		t.Errorf("the node of /tmp/devnet is not running")
+	}
+	if devnetProcessRunning(pid, "/tmp/other") {
+		t.Errorf("a process of another data path is the node of /tmp/other")
+	}
+	if devnetProcessRunning(os.Getpid(), "/tmp/devnet") {
+		t.Errorf("the test process is the node of /tmp/devnet")
+	}
+	if devnetProcessRunning(0, "/tmp/devnet") {
+		t.Errorf("pid 0 is running")
+	}
+	cmd.Process.Kill()
+	cmd.Wait()
+	if devnetProcessRunning(pid, "/tmp/devnet") {
+		t.Errorf("the node of /tmp/devnet is running after it was killed")
+	}
+}
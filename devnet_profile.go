@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/zenon-network/go-zenon/chain/genesis"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+	"gopkg.in/yaml.v3"
+)
+
+// chainProfile is the flavor of a generated chain: its native token pair,
+// the default extra data and the sporks of the genesis. Profiles are built in
+// or loaded from a yaml file with --profile.
+//
+//	name: mychain
+//	extraData: my chain
+//	znn:
+//	  tokenStandard: zts1znnxxxxxxxxxxxxx9z4ulx
+//	  name: tZNN
+//	  symbol: tZNN
+//	  domain: example.com
+//	qsr:
+//	  tokenStandard: zts1qsrxxxxxxxxxxxxxmrhjll
+//	  name: tQSR
+//	  symbol: tQSR
+//	  domain: example.com
+//	sporks:
+//	  - id: az
+//	    activated: true
+type chainProfile struct {
+	Name      string            `yaml:"name"`
+	ExtraData string            `yaml:"extraData"`
+	Znn       chainProfileToken `yaml:"znn"`
+	Qsr       chainProfileToken `yaml:"qsr"`
+	Sporks    []devnetSpecSpork `yaml:"sporks"`
+}
+
+type chainProfileToken struct {
+	TokenStandard string `yaml:"tokenStandard"`
+	Name          string `yaml:"name"`
+	Symbol        string `yaml:"symbol"`
+	Domain        string `yaml:"domain"`
+}
+
+var chainProfiles = map[string]*chainProfile{
+	// by default activate all implemented sporks at height 0
+	"nom": {
+		Name:      "nom",
+		ExtraData: "/thank_you_bich_dao",
+		Znn: chainProfileToken{
+			TokenStandard: types.ZnnTokenStandard.String(),
+			Name:          "tZNN",
+			Symbol:        "tZNN",
+			Domain:        "biginches.club",
+		},
+		Qsr: chainProfileToken{
+			TokenStandard: types.QsrTokenStandard.String(),
+			Name:          "tQSR",
+			Symbol:        "tQSR",
+			Domain:        "biginches.club",
+		},
+		Sporks: []devnetSpecSpork{
+			{Id: "az", Activated: true},
+			{Id: "htlc", Activated: true},
+			{Id: "bridge-liq", Activated: true},
+		},
+	},
+	// activate sporks for accelerator-z and htlc, create the bridge and
+	// pillar registration sporks but do not activate them
+	"hyperqube": {
+		Name:      "hyperqube",
+		ExtraData: "HYPERQUBE LOCAL UNIFORM 60",
+		Znn: chainProfileToken{
+			TokenStandard: utilZ.String(),
+			Name:          "utilZ",
+			Symbol:        "utilZ",
+			Domain:        "hyperqube.network",
+		},
+		Qsr: chainProfileToken{
+			TokenStandard: utilQ.String(),
+			Name:          "utilQ",
+			Symbol:        "utilQ",
+			Domain:        "hyperqube.network",
+		},
+		Sporks: []devnetSpecSpork{
+			{Id: "az", Activated: true},
+			{Id: "htlc", Activated: true},
+			{Id: "bridge-liq", Activated: false},
+			{Id: "hyperqube-no-pillar-reg", Activated: false},
+		},
+	},
+}
+
+// loadChainProfile returns a built-in profile or reads one from a file. An
+// empty name selects hyperqube with --hyperqube and nom otherwise.
+func loadChainProfile(name string) (*chainProfile, error) {
+	if name == "" {
+		name = "nom"
+		if hyperqube {
+			name = "hyperqube"
+		}
+	}
+	if profile, ok := chainProfiles[strings.ToLower(name)]; ok {
+		return profile, nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s is neither built in (nom, hyperqube) nor a readable file: %w", name, err)
+	}
+	profile := new(chainProfile)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(profile); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", name, err)
+	}
+	if err := profile.validate(); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", name, err)
+	}
+	return profile, nil
+}
+
+func (p *chainProfile) validate() error {
+	znn, err := types.ParseZTS(p.Znn.TokenStandard)
+	if err != nil {
+		return fmt.Errorf("znn: invalid token standard %q", p.Znn.TokenStandard)
+	}
+	qsr, err := types.ParseZTS(p.Qsr.TokenStandard)
+	if err != nil {
+		return fmt.Errorf("qsr: invalid token standard %q", p.Qsr.TokenStandard)
+	}
+	if znn == qsr {
+		return fmt.Errorf("znn and qsr must be different tokens")
+	}
+	if err := validateExtraData(p.ExtraData); err != nil {
+		return err
+	}
+	for i, s := range p.Sporks {
+		if err := s.validate(fmt.Sprintf("sporks[%d]", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNativeTokens checks that a node finds the native tokens of a
+// genesis. With a HYPERQUBE extra data header the node adopts the first two
+// tokens, otherwise they have to be ZNN and QSR.
+func validateNativeTokens(gen *genesis.GenesisConfig) error {
+	if strings.Split(gen.ExtraData, " ")[0] == "HYPERQUBE" {
+		return nil
+	}
+	tokens := gen.TokenConfig.Tokens
+	if len(tokens) < 2 || tokens[0].TokenStandard != types.ZnnTokenStandard || tokens[1].TokenStandard != types.QsrTokenStandard {
+		return fmt.Errorf("without a HYPERQUBE extra data header the native tokens must be %v and %v", types.ZnnTokenStandard, types.QsrTokenStandard)
+	}
+	return nil
+}
+
+func (t chainProfileToken) tokenInfo(totalSupply *big.Int) *definition.TokenInfo {
+	return &definition.TokenInfo{
+		Decimals:      8,
+		IsBurnable:    true,
+		IsMintable:    true,
+		IsUtility:     true,
+		MaxSupply:     big.NewInt(9007199254740991),
+		Owner:         types.TokenContract,
+		TokenDomain:   t.Domain,
+		TokenName:     t.Name,
+		TokenStandard: types.ParseZTSPanic(t.TokenStandard),
+		TokenSymbol:   t.Symbol,
+		TotalSupply:   totalSupply,
+	}
+}
+
+func (p *chainProfile) sporks() []*definition.Spork {
+	sporks := make([]*definition.Spork, len(p.Sporks))
+	for i, s := range p.Sporks {
+		sporks[i] = s.spork()
+	}
+	return sporks
+}
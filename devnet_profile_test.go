@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/zenon-network/go-zenon/chain/genesis"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+)
+
+func TestValidateNativeTokens(t *testing.T) {
+	tests := []struct {
+		extraData string
+		tokens    []types.ZenonTokenStandard
+		valid     bool
+	}{
+		{"/thank_you_bich_dao", []types.ZenonTokenStandard{types.ZnnTokenStandard, types.QsrTokenStandard}, true},
+		{"/thank_you_bich_dao", []types.ZenonTokenStandard{types.QsrTokenStandard, types.ZnnTokenStandard}, false},
+		{"/thank_you_bich_dao", []types.ZenonTokenStandard{utilZ, utilQ}, false},
+		{"/thank_you_bich_dao", []types.ZenonTokenStandard{types.ZnnTokenStandard}, false},
+		{"HYPERQUBE LOCAL UNIFORM 60", []types.ZenonTokenStandard{utilZ, utilQ}, true},
+		{"HYPERQUBE LOCAL UNIFORM 60", []types.ZenonTokenStandard{types.ZnnTokenStandard, types.QsrTokenStandard}, true},
+		{"hyperqube local uniform 60", []types.ZenonTokenStandard{utilZ, utilQ}, false},
+	}
+	for _, tt := range tests {
+		gen := &genesis.GenesisConfig{ExtraData: tt.extraData, TokenConfig: &genesis.TokenContractConfig{}}
+		for _, zts := range tt.tokens {
+			gen.TokenConfig.Tokens = append(gen.TokenConfig.Tokens, &definition.TokenInfo{TokenStandard: zts})
+		}
+		if err := validateNativeTokens(gen); (err == nil) != tt.valid {
+			t.Errorf("validateNativeTokens(%q, %v) = %v, want valid %v", tt.extraData, tt.tokens, err, tt.valid)
+		}
+	}
+}
+
+func TestBuiltInProfilesAreValid(t *testing.T) {
+	for name, profile := range chainProfiles {
+		if err := profile.validate(); err != nil {
+			t.Errorf("profile %s: %v", name, err)
+		}
+	}
+}
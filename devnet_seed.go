@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Seeded devnet timestamps are derived within one year after this time
+const devnetSeedTimestampBase = 1700000000
+
+// devnetEntropy provides the randomness of generate-devnet. Without a seed it
+// reads crypto/rand, with --seed every value is HMAC-SHA256(seed, label) so
+// that the same seed and flags produce identical genesis and config files.
+type devnetEntropy struct {
+	seed []byte
+}
+
+func newDevnetEntropy(seed string) *devnetEntropy {
+	if len(seed) == 0 {
+		return &devnetEntropy{}
+	}
+	return &devnetEntropy{seed: []byte(seed)}
+}
+
+func (e *devnetEntropy) seeded() bool {
+	return e.seed != nil
+}
+
+// read returns 32 bytes for label
+func (e *devnetEntropy) read(label string) ([]byte, error) {
+	if !e.seeded() {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	mac := hmac.New(sha256.New, e.seed)
+	mac.Write([]byte(label))
+	return mac.Sum(nil), nil
+}
+
+func (e *devnetEntropy) networkKey(label string) (*ecdsa.PrivateKey, error) {
+	if !e.seeded() {
+		return crypto.GenerateKey()
+	}
+	// retry with a counter in the unlikely case the bytes are not a valid scalar
+	for i := 0; ; i++ {
+		b, err := e.read(fmt.Sprintf("%s/%d", label, i))
+		if err != nil {
+			return nil, err
+		}
+		if key, err := crypto.ToECDSA(b); err == nil {
+			return key, nil
+		}
+	}
+}
+
+func (e *devnetEntropy) timestamp() (int64, error) {
+	b, err := e.read("genesis-timestamp")
+	if err != nil {
+		return 0, err
+	}
+	return devnetSeedTimestampBase + int64(binary.BigEndian.Uint64(b)%(365*24*3600)), nil
+}
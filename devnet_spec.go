@@ -0,0 +1,767 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/chain/genesis"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/constants"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+	"gopkg.in/yaml.v3"
+)
+
+// devnetSpec is a declarative description of a devnet genesis, loaded with
+// --spec and compiled into a genesis.GenesisConfig on top of the built-in
+// defaults. Amounts are in whole coins and may have decimals. Addresses are
+// bech32 or "local" for the generated producer. Token keys are znn and qsr
+// for the native tokens of the chain or a token standard.
+//
+//	chainId: 321
+//	extraData: my devnet
+//	timestamp: 1700000000 # unix, RFC 3339 or an offset from now like -1h
+//	sporkAddress: local
+//	tokens:
+//	  - tokenStandard: zts1...
+//	    name: Test
+//	    symbol: TST
+//	    domain: example.com
+//	    decimals: 8
+//	    maxSupply: "1000000"
+//	pillars:
+//	  - name: Second
+//	    owner: z1...
+//	    producer: z1...
+//	delegations:
+//	  - address: z1...
+//	    pillar: Second
+//	fusions:
+//	  - owner: local
+//	    amount: "1000"
+//	    beneficiary: z1... # defaults to the owner
+//	    expirationHeight: 100 # defaults to 1
+//	sporks:
+//	  - id: htlc
+//	    activated: false
+//	balances:
+//	  - address: z1...
+//	    amounts:
+//	      znn: "1000"
+//	      qsr: "10000.5"
+type devnetSpec struct {
+	ChainId      *uint64                `yaml:"chainId"`
+	ExtraData    *string                `yaml:"extraData"`
+	Timestamp    string                 `yaml:"timestamp"`
+	SporkAddress string                 `yaml:"sporkAddress"`
+	Tokens       []devnetSpecToken      `yaml:"tokens"`
+	Pillars      []devnetSpecPillar     `yaml:"pillars"`
+	Delegations  []devnetSpecDelegation `yaml:"delegations"`
+	Fusions      []devnetSpecFusion     `yaml:"fusions"`
+	Sporks       []devnetSpecSpork      `yaml:"sporks"`
+	Balances     []devnetSpecBalance    `yaml:"balances"`
+
+	// The genesis format has no stake or sentinel contract config, these
+	// are only accepted to report a clear error
+	Stakes    []yaml.Node `yaml:"stakes"`
+	Sentinels []yaml.Node `yaml:"sentinels"`
+}
+
+type devnetSpecToken struct {
+	TokenStandard string `yaml:"tokenStandard"`
+	Name          string `yaml:"name"`
+	Symbol        string `yaml:"symbol"`
+	Domain        string `yaml:"domain"`
+	Decimals      *uint8 `yaml:"decimals"`
+	MaxSupply     string `yaml:"maxSupply"`
+	Owner         string `yaml:"owner"`
+	Mintable      *bool  `yaml:"mintable"`
+	Burnable      *bool  `yaml:"burnable"`
+	Utility       *bool  `yaml:"utility"`
+}
+
+type devnetSpecPillar struct {
+	Name                         string `yaml:"name"`
+	Owner                        string `yaml:"owner"`
+	Producer                     string `yaml:"producer"`
+	Reward                       string `yaml:"reward"`
+	Amount                       string `yaml:"amount"`
+	GiveBlockRewardPercentage    uint8  `yaml:"giveBlockRewardPercentage"`
+	GiveDelegateRewardPercentage *uint8 `yaml:"giveDelegateRewardPercentage"`
+}
+
+type devnetSpecDelegation struct {
+	Address string `yaml:"address"`
+	Pillar  string `yaml:"pillar"`
+}
+
+type devnetSpecFusion struct {
+	Owner            string  `yaml:"owner"`
+	Amount           string  `yaml:"amount"`
+	Beneficiary      string  `yaml:"beneficiary"`
+	ExpirationHeight *uint64 `yaml:"expirationHeight"`
+	// Id defaults to a hash of the owner and the position of the fusion
+	Id string `yaml:"id"`
+}
+
+type devnetSpecSpork struct {
+	Id                string `yaml:"id"`
+	Activated         bool   `yaml:"activated"`
+	EnforcementHeight uint64 `yaml:"enforcementHeight"`
+}
+
+type devnetSpecBalance struct {
+	Address string            `yaml:"address"`
+	Amounts map[string]string `yaml:"amounts"`
+}
+
+// loadDevnetSpec reads --spec, overlays the genesis flags and validates the result
+func loadDevnetSpec(ctx *cli.Context) (*devnetSpec, error) {
+	spec := new(devnetSpec)
+	if file := ctx.String(SpecFileFlag.Name); ctx.IsSet(SpecFileFlag.Name) && len(file) > 0 {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(spec); err != nil {
+			return nil, fmt.Errorf("invalid spec file %s: %w", file, err)
+		}
+	}
+	spec.applyFlags(ctx)
+	if err := spec.validate(ctx.Int(PillarsFlag.Name)); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// applyFlags overlays the genesis flags on the spec. Flags win over spec
+// entries for the same address or spork.
+func (spec *devnetSpec) applyFlags(ctx *cli.Context) {
+	if ctx.Bool(GenesisEZFlag.Name) {
+		spec.setBalance("local", "znn", "100000")
+		spec.setBalance("local", "qsr", "500000")
+	}
+
+	for _, s := range ctx.StringSlice(GenesisBlockFlag.Name) {
+		ss := strings.Split(s, "/")
+		spec.setBalance(ss[0], "znn", ss[1])
+		spec.setBalance(ss[0], "qsr", ss[2])
+	}
+
+	for _, s := range ctx.StringSlice(GenesisDelegationFlag.Name) {
+		ss := strings.SplitN(s, "/", 2)
+		spec.setDelegation(ss[0], ss[1])
+	}
+
+	if ctx.IsSet(GenesisFusionFlag.Name) || ctx.Bool(GenesisEZFlag.Name) {
+		spec.Fusions = append(spec.Fusions, devnetSpecFusion{Owner: "local", Amount: "1000"})
+		for _, s := range ctx.StringSlice(GenesisFusionFlag.Name) {
+			ss := strings.Split(s, "/")
+			fusion := devnetSpecFusion{Owner: ss[0], Amount: ss[1]}
+			if len(ss) > 2 && ss[2] != "" {
+				fusion.Beneficiary = ss[2]
+			}
+			if len(ss) > 3 {
+				expirationHeight, _ := strconv.ParseUint(ss[3], 10, 64)
+				fusion.ExpirationHeight = &expirationHeight
+			}
+			spec.Fusions = append(spec.Fusions, fusion)
+		}
+	}
+
+	if ctx.IsSet(ChainIdFlag.Name) {
+		chainId := ctx.Uint64(ChainIdFlag.Name)
+		spec.ChainId = &chainId
+	}
+	if ctx.IsSet(ExtraDataFlag.Name) {
+		extraData := ctx.String(ExtraDataFlag.Name)
+		spec.ExtraData = &extraData
+	}
+	if ctx.IsSet(GenesisTimestampFlag.Name) {
+		spec.Timestamp = ctx.String(GenesisTimestampFlag.Name)
+	}
+
+	if ctx.IsSet(SporkAddressFlag.Name) {
+		spec.SporkAddress = ctx.String(SporkAddressFlag.Name)
+	}
+	for _, s := range rawSliceValues(ctx, GenesisSporkFlag.Name) {
+		ss := strings.Split(s, ",")
+		spork := devnetSpecSpork{Id: ss[0]}
+		spork.Activated, _ = strconv.ParseBool(ss[1])
+		if len(ss) == 3 {
+			spork.EnforcementHeight, _ = strconv.ParseUint(ss[2], 10, 64)
+		}
+		spec.Sporks = append(spec.Sporks, spork)
+	}
+}
+
+func (spec *devnetSpec) setDelegation(address string, pillar string) {
+	for i := range spec.Delegations {
+		if spec.Delegations[i].Address == address {
+			spec.Delegations[i].Pillar = pillar
+			return
+		}
+	}
+	spec.Delegations = append(spec.Delegations, devnetSpecDelegation{Address: address, Pillar: pillar})
+}
+
+func (spec *devnetSpec) setBalance(address string, token string, amount string) {
+	for i := range spec.Balances {
+		if spec.Balances[i].Address == address {
+			if spec.Balances[i].Amounts == nil {
+				spec.Balances[i].Amounts = make(map[string]string)
+			}
+			spec.Balances[i].Amounts[token] = amount
+			return
+		}
+	}
+	spec.Balances = append(spec.Balances, devnetSpecBalance{
+		Address: address,
+		Amounts: map[string]string{token: amount},
+	})
+}
+
+// parseGenesisTimestamp accepts unix seconds, RFC 3339 or a duration
+// relative to now prefixed with + or -
+func parseGenesisTimestamp(s string, now time.Time) (int64, error) {
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid genesis timestamp offset %q: %w", s, err)
+		}
+		return now.Add(d).Unix(), nil
+	}
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return unix, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, fmt.Errorf("genesis timestamp %q must be unix seconds, RFC 3339 or an offset like -1h", s)
+	}
+	return t.Unix(), nil
+}
+
+// validateExtraData checks the header a node parses from the extra data. When
+// the first word is HYPERQUBE the node reads the consensus algorithm and the
+// block time in seconds from the third and fourth word, e.g.
+// "HYPERQUBE LOCAL UNIFORM 60".
+func validateExtraData(s string) error {
+	args := strings.Split(s, " ")
+	if args[0] != "HYPERQUBE" {
+		return nil
+	}
+	if len(args) != 4 {
+		return fmt.Errorf("extra data %q must be in the format HYPERQUBE <name> UNIFORM <blockTime>", s)
+	}
+	if args[2] != "UNIFORM" {
+		return fmt.Errorf("extra data %q: UNIFORM is the only supported consensus algorithm", s)
+	}
+	blockTime, err := strconv.Atoi(args[3])
+	if err != nil || blockTime < 1 || blockTime > 3600 {
+		return fmt.Errorf("extra data %q: block time must be between 1 and 3600 seconds", s)
+	}
+	return nil
+}
+
+func (s devnetSpecSpork) validate(field string) error {
+	if _, ok := devnetSporkNames[strings.ToLower(s.Id)]; !ok {
+		if _, err := types.HexToHash(s.Id); err != nil {
+			return fmt.Errorf("%s: id must be a spork hash or one of az, htlc, bridge-liq, hyperqube-no-pillar-reg", field)
+		}
+	}
+	if !s.Activated && s.EnforcementHeight != 0 {
+		return fmt.Errorf("%s: enforcement height can only be set for activated sporks", field)
+	}
+	return nil
+}
+
+// spork converts a validated spork entry
+func (s devnetSpecSpork) spork() *definition.Spork {
+	id, ok := devnetSporkNames[strings.ToLower(s.Id)]
+	if !ok {
+		id = types.HexToHashPanic(s.Id)
+	}
+	name := sporkName(id)
+	return &definition.Spork{
+		Id:                id,
+		Name:              name,
+		Description:       name,
+		Activated:         s.Activated,
+		EnforcementHeight: s.EnforcementHeight,
+	}
+}
+
+func parseSpecAddress(s string, local types.Address) (types.Address, error) {
+	if s == "local" {
+		return local, nil
+	}
+	a, err := types.ParseAddress(s)
+	if err != nil {
+		return types.ZeroAddress, fmt.Errorf("invalid address %q: %w", s, err)
+	}
+	return a, nil
+}
+
+func validateSpecAddress(s string, field string) error {
+	if s == "local" {
+		return nil
+	}
+	a, err := types.ParseAddress(s)
+	if err != nil {
+		return fmt.Errorf("%s: invalid address %q", field, s)
+	}
+	if types.IsEmbeddedAddress(a) {
+		return fmt.Errorf("%s: %s is an embedded contract address", field, s)
+	}
+	return nil
+}
+
+func validateSpecAmount(s string, field string) error {
+	amount, err := parseAmount(s, 8)
+	if err != nil {
+		return fmt.Errorf("%s: invalid amount %q", field, s)
+	}
+	if amount.Sign() < 0 {
+		return fmt.Errorf("%s: amount cannot be negative", field)
+	}
+	return nil
+}
+
+// validate checks the spec, pillars is the number of generated pillars
+func (spec *devnetSpec) validate(pillars int) error {
+	if len(spec.Stakes) > 0 || len(spec.Sentinels) > 0 {
+		return errors.New("stakes and sentinels cannot be seeded, the genesis format only supports pillars, delegations, fusions and balances. Stake and register sentinels with znn-cli after the devnet started")
+	}
+	if spec.ChainId != nil && *spec.ChainId == 0 {
+		return errors.New("chainId cannot be 0")
+	}
+	if spec.ExtraData != nil {
+		if err := validateExtraData(*spec.ExtraData); err != nil {
+			return err
+		}
+	}
+	if spec.Timestamp != "" {
+		if _, err := parseGenesisTimestamp(spec.Timestamp, time.Now()); err != nil {
+			return err
+		}
+	}
+	if spec.SporkAddress != "" {
+		if err := validateSpecAddress(spec.SporkAddress, "sporkAddress"); err != nil {
+			return err
+		}
+	}
+
+	for i, t := range spec.Tokens {
+		field := fmt.Sprintf("tokens[%d]", i)
+		if _, err := types.ParseZTS(t.TokenStandard); err != nil {
+			return fmt.Errorf("%s: invalid token standard %q", field, t.TokenStandard)
+		}
+		if t.MaxSupply != "" {
+			if err := validateSpecAmount(t.MaxSupply, field+".maxSupply"); err != nil {
+				return err
+			}
+		}
+		if t.Owner != "" {
+			if _, err := types.ParseAddress(t.Owner); err != nil && t.Owner != "local" {
+				return fmt.Errorf("%s.owner: invalid address %q", field, t.Owner)
+			}
+		}
+	}
+
+	pillarNames := make(map[string]bool)
+	for i := 0; i < pillars; i++ {
+		pillarNames[devnetPillarName(i)] = true
+	}
+	for i, p := range spec.Pillars {
+		field := fmt.Sprintf("pillars[%d]", i)
+		if len(p.Name) == 0 || len(p.Name) > constants.PillarNameLengthMax {
+			return fmt.Errorf("%s: name must be 1 to %d characters", field, constants.PillarNameLengthMax)
+		}
+		if pillarNames[p.Name] {
+			return fmt.Errorf("%s: pillar name %s is not unique", field, p.Name)
+		}
+		pillarNames[p.Name] = true
+		for _, a := range []string{p.Owner, p.Producer} {
+			if err := validateSpecAddress(a, field); err != nil {
+				return err
+			}
+		}
+		if p.Reward != "" {
+			if err := validateSpecAddress(p.Reward, field+".reward"); err != nil {
+				return err
+			}
+		}
+		if p.Amount != "" {
+			if err := validateSpecAmount(p.Amount, field+".amount"); err != nil {
+				return err
+			}
+		}
+		if p.GiveBlockRewardPercentage > 100 || (p.GiveDelegateRewardPercentage != nil && *p.GiveDelegateRewardPercentage > 100) {
+			return fmt.Errorf("%s: reward percentages must be between 0 and 100", field)
+		}
+	}
+
+	delegated := make(map[string]bool)
+	for i, d := range spec.Delegations {
+		field := fmt.Sprintf("delegations[%d]", i)
+		if err := validateSpecAddress(d.Address, field); err != nil {
+			return err
+		}
+		if !pillarNames[d.Pillar] {
+			return fmt.Errorf("%s: pillar %s does not exist", field, d.Pillar)
+		}
+		if delegated[d.Address] {
+			return fmt.Errorf("%s: %s can only delegate to one pillar", field, d.Address)
+		}
+		delegated[d.Address] = true
+	}
+
+	fusionIds := make(map[types.Hash]bool)
+	for i, f := range spec.Fusions {
+		field := fmt.Sprintf("fusions[%d]", i)
+		if err := validateSpecAddress(f.Owner, field); err != nil {
+			return err
+		}
+		if err := validateSpecAmount(f.Amount, field+".amount"); err != nil {
+			return err
+		}
+		if f.Beneficiary != "" {
+			if err := validateSpecAddress(f.Beneficiary, field+".beneficiary"); err != nil {
+				return err
+			}
+		}
+		if f.Id != "" {
+			id, err := types.HexToHash(f.Id)
+			if err != nil {
+				return fmt.Errorf("%s: invalid id %q", field, f.Id)
+			}
+			if fusionIds[id] {
+				return fmt.Errorf("%s: fusion id %s is not unique", field, f.Id)
+			}
+			fusionIds[id] = true
+		}
+	}
+
+	for i, s := range spec.Sporks {
+		if err := s.validate(fmt.Sprintf("sporks[%d]", i)); err != nil {
+			return err
+		}
+	}
+
+	for i, b := range spec.Balances {
+		field := fmt.Sprintf("balances[%d]", i)
+		if err := validateSpecAddress(b.Address, field); err != nil {
+			return err
+		}
+		for token, amount := range b.Amounts {
+			if l := strings.ToLower(token); l != "znn" && l != "qsr" {
+				if _, err := getTokenStandard(token); err != nil {
+					return fmt.Errorf("%s: invalid token %q", field, token)
+				}
+			}
+			if err := validateSpecAmount(amount, field+"."+token); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func findGenesisBlock(gen *genesis.GenesisConfig, address types.Address) *genesis.GenesisBlockConfig {
+	for _, block := range gen.GenesisBlocks.Blocks {
+		if block.Address == address {
+			return block
+		}
+	}
+	block := &genesis.GenesisBlockConfig{
+		Address:     address,
+		BalanceList: map[types.ZenonTokenStandard]*big.Int{},
+	}
+	gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, block)
+	return block
+}
+
+func findGenesisToken(gen *genesis.GenesisConfig, zts types.ZenonTokenStandard) *definition.TokenInfo {
+	for _, token := range gen.TokenConfig.Tokens {
+		if token.TokenStandard == zts {
+			return token
+		}
+	}
+	return nil
+}
+
+// findGenesisDelegation returns the delegation of backer, an address can only
+// delegate to one pillar
+func findGenesisDelegation(gen *genesis.GenesisConfig, backer types.Address) *definition.DelegationInfo {
+	for _, delegation := range gen.PillarConfig.Delegations {
+		if delegation.Backer == backer {
+			return delegation
+		}
+	}
+	return nil
+}
+
+// addGenesisBalance credits an account-chain and increases the token supply
+func addGenesisBalance(gen *genesis.GenesisConfig, address types.Address, zts types.ZenonTokenStandard, amount *big.Int) error {
+	token := findGenesisToken(gen, zts)
+	if token == nil {
+		return fmt.Errorf("token %v is not declared in the genesis", zts)
+	}
+	block := findGenesisBlock(gen, address)
+	if balance, ok := block.BalanceList[zts]; ok {
+		balance.Add(balance, amount)
+	} else {
+		block.BalanceList[zts] = new(big.Int).Set(amount)
+	}
+	token.TotalSupply.Add(token.TotalSupply, amount)
+	return nil
+}
+
+// compile applies the spec to a genesis containing the native tokens of the
+// chain as first and second token
+func (spec *devnetSpec) compile(gen *genesis.GenesisConfig, local types.Address) error {
+	nativeZ := gen.TokenConfig.Tokens[0].TokenStandard
+	nativeQ := gen.TokenConfig.Tokens[1].TokenStandard
+	resolveToken := func(s string) (types.ZenonTokenStandard, error) {
+		switch strings.ToLower(s) {
+		case "znn":
+			return nativeZ, nil
+		case "qsr":
+			return nativeQ, nil
+		}
+		return getTokenStandard(s)
+	}
+
+	if spec.ChainId != nil {
+		gen.ChainIdentifier = *spec.ChainId
+	}
+	if spec.ExtraData != nil {
+		gen.ExtraData = *spec.ExtraData
+	}
+	if spec.Timestamp != "" {
+		timestamp, err := parseGenesisTimestamp(spec.Timestamp, time.Now())
+		if err != nil {
+			return err
+		}
+		gen.GenesisTimestampSec = timestamp
+	}
+	if spec.SporkAddress != "" {
+		a, err := parseSpecAddress(spec.SporkAddress, local)
+		if err != nil {
+			return err
+		}
+		gen.SporkAddress = &a
+	}
+
+	// Tokens
+	for _, t := range spec.Tokens {
+		zts, _ := types.ParseZTS(t.TokenStandard)
+		token := findGenesisToken(gen, zts)
+		if token == nil {
+			token = &definition.TokenInfo{
+				Decimals:      8,
+				IsBurnable:    true,
+				IsMintable:    true,
+				MaxSupply:     big.NewInt(9007199254740991),
+				Owner:         types.TokenContract,
+				TokenStandard: zts,
+				TotalSupply:   big.NewInt(0),
+			}
+			gen.TokenConfig.Tokens = append(gen.TokenConfig.Tokens, token)
+		}
+		if t.Name != "" {
+			token.TokenName = t.Name
+		}
+		if t.Symbol != "" {
+			token.TokenSymbol = t.Symbol
+		}
+		if t.Domain != "" {
+			token.TokenDomain = t.Domain
+		}
+		if t.Decimals != nil && *t.Decimals != token.Decimals {
+			if token.TotalSupply.Sign() != 0 {
+				return fmt.Errorf("cannot change the decimals of %v after balances were assigned", zts)
+			}
+			token.Decimals = *t.Decimals
+		}
+		if t.Owner != "" {
+			owner, err := parseSpecAddress(t.Owner, local)
+			if err != nil {
+				return err
+			}
+			token.Owner = owner
+		}
+		if t.Mintable != nil {
+			token.IsMintable = *t.Mintable
+		}
+		if t.Burnable != nil {
+			token.IsBurnable = *t.Burnable
+		}
+		if t.Utility != nil {
+			token.IsUtility = *t.Utility
+		}
+		if t.MaxSupply != "" {
+			maxSupply, err := parseAmount(t.MaxSupply, token.Decimals)
+			if err != nil {
+				return err
+			}
+			token.MaxSupply = maxSupply
+		}
+	}
+
+	// Sporks
+	overrides := make([]*definition.Spork, 0, len(spec.Sporks))
+	for _, s := range spec.Sporks {
+		overrides = append(overrides, s.spork())
+	}
+	gen.SporkConfig.Sporks = applyGenesisSporks(overrides, gen.SporkConfig.Sporks)
+
+	// Pillars
+	for _, p := range spec.Pillars {
+		owner, err := parseSpecAddress(p.Owner, local)
+		if err != nil {
+			return err
+		}
+		producer, err := parseSpecAddress(p.Producer, local)
+		if err != nil {
+			return err
+		}
+		reward := owner
+		if p.Reward != "" {
+			if reward, err = parseSpecAddress(p.Reward, local); err != nil {
+				return err
+			}
+		}
+		amount := new(big.Int).Set(constants.PillarStakeAmount)
+		if p.Amount != "" {
+			if amount, err = parseAmount(p.Amount, 8); err != nil {
+				return err
+			}
+		}
+		delegatePercentage := uint8(100)
+		if p.GiveDelegateRewardPercentage != nil {
+			delegatePercentage = *p.GiveDelegateRewardPercentage
+		}
+		gen.PillarConfig.Pillars = append(gen.PillarConfig.Pillars, &definition.PillarInfo{
+			Name:                         p.Name,
+			Amount:                       amount,
+			BlockProducingAddress:        producer,
+			StakeAddress:                 owner,
+			RewardWithdrawAddress:        reward,
+			PillarType:                   1,
+			RevokeTime:                   0,
+			GiveBlockRewardPercentage:    p.GiveBlockRewardPercentage,
+			GiveDelegateRewardPercentage: delegatePercentage,
+		})
+		if err := addGenesisBalance(gen, types.PillarContract, nativeZ, amount); err != nil {
+			return err
+		}
+	}
+
+	// Delegations
+	for _, d := range spec.Delegations {
+		a, err := parseSpecAddress(d.Address, local)
+		if err != nil {
+			return err
+		}
+		delegation := findGenesisDelegation(gen, a)
+		if delegation == nil {
+			delegation = &definition.DelegationInfo{Backer: a}
+			gen.PillarConfig.Delegations = append(gen.PillarConfig.Delegations, delegation)
+		}
+		delegation.Name = d.Pillar
+	}
+
+	// Balances, merged per account-chain
+	for _, b := range spec.Balances {
+		a, err := parseSpecAddress(b.Address, local)
+		if err != nil {
+			return err
+		}
+		tokens := make([]string, 0, len(b.Amounts))
+		for token := range b.Amounts {
+			tokens = append(tokens, token)
+		}
+		sort.Strings(tokens)
+		for _, token := range tokens {
+			zts, err := resolveToken(token)
+			if err != nil {
+				return err
+			}
+			decimals := uint8(8)
+			if t := findGenesisToken(gen, zts); t != nil {
+				decimals = t.Decimals
+			}
+			amount, err := parseAmount(b.Amounts[token], decimals)
+			if err != nil {
+				return err
+			}
+			if amount.Sign() == 0 {
+				continue
+			}
+			if err := addGenesisBalance(gen, a, zts, amount); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Fusions
+	fusionIds := make(map[types.Hash]bool)
+	for _, fusion := range gen.PlasmaConfig.Fusions {
+		fusionIds[fusion.Id] = true
+	}
+	for i, f := range spec.Fusions {
+		a, err := parseSpecAddress(f.Owner, local)
+		if err != nil {
+			return err
+		}
+		qsr, err := parseAmount(f.Amount, 8)
+		if err != nil {
+			return err
+		}
+		beneficiary := a
+		if f.Beneficiary != "" {
+			if beneficiary, err = parseSpecAddress(f.Beneficiary, local); err != nil {
+				return err
+			}
+		}
+		expirationHeight := uint64(1)
+		if f.ExpirationHeight != nil {
+			expirationHeight = *f.ExpirationHeight
+		}
+		var id types.Hash
+		if f.Id != "" {
+			id = types.HexToHashPanic(f.Id)
+		} else {
+			index := make([]byte, 8)
+			binary.BigEndian.PutUint64(index, uint64(i))
+			id = types.NewHash(append(a.Bytes(), index...))
+		}
+		if fusionIds[id] {
+			return fmt.Errorf("fusion id %v of %v is not unique", id, a)
+		}
+		fusionIds[id] = true
+
+		gen.PlasmaConfig.Fusions = append(gen.PlasmaConfig.Fusions, &definition.FusionInfo{
+			Owner:            a,
+			Id:               id,
+			Amount:           qsr,
+			ExpirationHeight: expirationHeight,
+			Beneficiary:      beneficiary,
+		})
+		if err := addGenesisBalance(gen, types.PlasmaContract, nativeQ, qsr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseGenesisTimestamp(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1600000000", 1600000000},
+		{"0", 0},
+		{"2023-11-14T22:13:20Z", 1700000000},
+		{"2023-11-14T23:13:20+01:00", 1700000000},
+		{"+1h", 1700003600},
+		{"-90m", 1699994600},
+	}
+	for _, tt := range tests {
+		got, err := parseGenesisTimestamp(tt.in, now)
+		if err != nil {
+			t.Errorf("parseGenesisTimestamp(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseGenesisTimestamp(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "now", "+1x", "-", "2023-11-14", "1.5"} {
+		if got, err := parseGenesisTimestamp(in, now); err == nil {
+			t.Errorf("parseGenesisTimestamp(%q) = %d, want an error", in, got)
+		}
+	}
+}
+
+func TestValidateExtraData(t *testing.T) {
+	tests := []struct {
+		in    string
+		valid bool
+	}{
+		{"", true},
+		{"/thank_you_bich_dao", true},
+		{"hyperqube local", true},
+		{"HYPERQUBE LOCAL UNIFORM 60", true},
+		{"HYPERQUBE Z UNIFORM 1", true},
+		{"HYPERQUBE Z UNIFORM 3600", true},
+		{"HYPERQUBE", false},
+		{"HYPERQUBE LOCAL", false},
+		{"HYPERQUBE LOCAL UNIFORM", false},
+		{"HYPERQUBE LOCAL UNIFORM 60 extra", false},
+		{"HYPERQUBE  LOCAL UNIFORM 60", false},
+		{"HYPERQUBE LOCAL RANDOM 60", false},
+		{"HYPERQUBE LOCAL UNIFORM sixty", false},
+		{"HYPERQUBE LOCAL UNIFORM 0", false},
+		{"HYPERQUBE LOCAL UNIFORM -10", false},
+		{"HYPERQUBE LOCAL UNIFORM 3601", false},
+	}
+	for _, tt := range tests {
+		if err := validateExtraData(tt.in); (err == nil) != tt.valid {
+			t.Errorf("validateExtraData(%q) = %v, want valid %v", tt.in, err, tt.valid)
+		}
+	}
+}
+
+func TestDevnetSpecRejectsStakesAndSentinels(t *testing.T) {
+	for _, in := range []string{
+		"stakes:\n  - address: z1qzal6c5s9rjnnxd2z7dvdhjxpmmj4fmw56a0mz\n",
+		"sentinels:\n  - owner: z1qzal6c5s9rjnnxd2z7dvdhjxpmmj4fmw56a0mz\n",
+	} {
+		spec := new(devnetSpec)
+		decoder := yaml.NewDecoder(strings.NewReader(in))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(spec); err != nil {
+			t.Fatalf("decode %q: %v", in, err)
+		}
+		if err := spec.validate(1); err == nil || !strings.Contains(err.Error(), "cannot be seeded") {
+			t.Errorf("validate(%q) = %v, want the stakes and sentinels error", in, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"golang.org/x/crypto/argon2"
+)
+
+// encryptedData is a passphrase protected blob using the same primitives as
+// wallet.KeyFile: an argon2id derived key and aes-256-gcm, which also
+// authenticates the content.
+type encryptedData struct {
+	Type       string        `json:"type"`
+	Version    int           `json:"version"`
+	CipherName string        `json:"cipherName"`
+	KDF        string        `json:"kdf"`
+	Salt       hexutil.Bytes `json:"salt"`
+	Nonce      hexutil.Bytes `json:"nonce"`
+	CipherData hexutil.Bytes `json:"cipherData"`
+}
+
+const (
+	encryptedDataVersion = 1
+	encryptedDataCipher  = "aes-256-gcm"
+	encryptedDataKDF     = "argon2.IDKey"
+)
+
+var errWrongPassphrase = errors.New("wrong passphrase or corrupted data")
+
+func passphraseKey(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, 1, 64*1024, 4, 32)
+}
+
+func encryptWithPassphrase(dataType string, plain []byte, passphrase string) (*encryptedData, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(passphraseKey(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedData{
+		Type:       dataType,
+		Version:    encryptedDataVersion,
+		CipherName: encryptedDataCipher,
+		KDF:        encryptedDataKDF,
+		Salt:       salt,
+		Nonce:      nonce,
+		CipherData: gcm.Seal(nil, nonce, plain, []byte(dataType)),
+	}, nil
+}
+
+func (e *encryptedData) decrypt(passphrase string) ([]byte, error) {
+	if e.Version != encryptedDataVersion || e.CipherName != encryptedDataCipher || e.KDF != encryptedDataKDF {
+		return nil, errors.New("unsupported encryption parameters")
+	}
+	block, err := aes.NewCipher(passphraseKey(passphrase, e.Salt))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce length")
+	}
+	plain, err := gcm.Open(nil, e.Nonce, e.CipherData, []byte(e.Type))
+	if err != nil {
+		return nil, errWrongPassphrase
+	}
+	return plain, nil
+}
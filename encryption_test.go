@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncryptWithPassphrase(t *testing.T) {
+	plain := []byte(`{"entries":[]}`)
+	encrypted, err := encryptWithPassphrase(walletBundleType, plain, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := encrypted.decrypt("passphrase")
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Fatalf("decrypt = %q, want %q", got, plain)
+	}
+
+	other, err := encryptWithPassphrase(walletBundleType, plain, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(other.Salt, encrypted.Salt) || bytes.Equal(other.CipherData, encrypted.CipherData) {
+		t.Fatal("two encryptions share a salt or ciphertext")
+	}
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	encrypted, err := encryptWithPassphrase(walletBundleType, []byte("secret"), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		passphrase string
+		modify     func(e *encryptedData)
+		wrong      bool
+	}{
+		{"wrong passphrase", "Passphrase", func(e *encryptedData) {}, true},
+		{"empty passphrase", "", func(e *encryptedData) {}, true},
+		{"changed type", "passphrase", func(e *encryptedData) { e.Type = "other" }, true},
+		{"changed cipher data", "passphrase", func(e *encryptedData) { e.CipherData[0] ^= 1 }, true},
+		{"changed salt", "passphrase", func(e *encryptedData) { e.Salt[0] ^= 1 }, true},
+		{"short nonce", "passphrase", func(e *encryptedData) { e.Nonce = e.Nonce[:8] }, false},
+		{"unknown version", "passphrase", func(e *encryptedData) { e.Version = 2 }, false},
+		{"unknown kdf", "passphrase", func(e *encryptedData) { e.KDF = "scrypt" }, false},
+	}
+	for _, tt := range tests {
+		e := *encrypted
+		e.Salt = append(e.Salt[:0:0], encrypted.Salt...)
+		e.Nonce = append(e.Nonce[:0:0], encrypted.Nonce...)
+		e.CipherData = append(e.CipherData[:0:0], encrypted.CipherData...)
+		tt.modify(&e)
+		_, err := e.decrypt(tt.passphrase)
+		if err == nil {
+			t.Errorf("%s: decrypt succeeded, want an error", tt.name)
+		} else if errors.Is(err, errWrongPassphrase) != tt.wrong {
+			t.Errorf("%s: decrypt error = %v, want wrong passphrase %v", tt.name, err, tt.wrong)
+		}
+	}
+}
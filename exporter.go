@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypercore-one/go-zdk/zdk"
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+)
+
+const defaultExporterInterval = 30 * time.Second
+
+// exporterConfig lists what the exporter polls. Addresses can be @labels of
+// the address book, no pillars exports all of them.
+type exporterConfig struct {
+	Interval  string   `json:"interval"`
+	Addresses []string `json:"addresses"`
+	Pillars   []string `json:"pillars"`
+}
+
+type exporterTarget struct {
+	address types.Address
+	label   string
+}
+
+type exporterSample struct {
+	labels []string
+	value  float64
+}
+
+type exporterFamily struct {
+	name, help, kind string
+	samples          []exporterSample
+}
+
+// exporterMetrics is a set of metrics in the Prometheus text format, families
+// are written in the order they were added
+type exporterMetrics struct {
+	families []*exporterFamily
+	index    map[string]*exporterFamily
+}
+
+func newExporterMetrics() *exporterMetrics {
+	return &exporterMetrics{index: make(map[string]*exporterFamily)}
+}
+
+// add records a sample, labels are name and value pairs
+func (m *exporterMetrics) add(name, help, kind string, value float64, labels ...string) {
+	family, ok := m.index[name]
+	if !ok {
+		family = &exporterFamily{name: name, help: help, kind: kind}
+		m.index[name] = family
+		m.families = append(m.families, family)
+	}
+	family.samples = append(family.samples, exporterSample{labels: labels, value: value})
+}
+
+var exporterLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (m *exporterMetrics) write(w io.Writer) {
+	for _, family := range m.families {
+		fmt.Fprintf(w, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", family.name, family.kind)
+		for _, sample := range family.samples {
+			fmt.Fprint(w, family.name)
+			if len(sample.labels) > 0 {
+				pairs := make([]string, 0, len(sample.labels)/2)
+				for i := 0; i+1 < len(sample.labels); i += 2 {
+					pairs = append(pairs, fmt.Sprintf(`%s="%s"`, sample.labels[i], exporterLabelEscaper.Replace(sample.labels[i+1])))
+				}
+				fmt.Fprintf(w, "{%s}", strings.Join(pairs, ","))
+			}
+			fmt.Fprintf(w, " %v\n", sample.value)
+		}
+	}
+}
+
+// amountFloat converts an amount in base units to a float
+func amountFloat(amount *big.Int, decimals uint8) float64 {
+	if amount == nil {
+		return 0
+	}
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(amount), new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))).Float64()
+	return f
+}
+
+type exporter struct {
+	targets []exporterTarget
+	pillars map[string]bool
+
+	mu       sync.Mutex
+	metrics  *exporterMetrics
+	up       bool
+	frontier time.Time
+	lastPoll time.Time
+}
+
+// poll queries the same data as balance, plasma.get, stake.list,
+// *.uncollected and pillar.list
+func (e *exporter) poll(z *zdk.Zdk) (*exporterMetrics, time.Time, error) {
+	m := newExporterMetrics()
+
+	momentum, err := z.Ledger.GetFrontierMomentum()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	m.add("nomctl_frontier_height", "Height of the frontier momentum", "gauge", float64(momentum.Height))
+	frontier := time.Unix(int64(momentum.TimestampUnix), 0)
+
+	for _, target := range e.targets {
+		address := target.address.String()
+
+		info, err := z.Ledger.GetAccountInfoByAddress(target.address)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		tokens := make([]types.ZenonTokenStandard, 0, len(info.BalanceInfoMap))
+		for zts := range info.BalanceInfoMap {
+			tokens = append(tokens, zts)
+		}
+		sort.Slice(tokens, func(i, j int) bool { return tokens[i].String() < tokens[j].String() })
+		for _, zts := range tokens {
+			entry := info.BalanceInfoMap[zts]
+			m.add("nomctl_balance", "Balance of an address per token", "gauge", amountFloat(entry.Balance, entry.TokenInfo.Decimals),
+				"address", address, "label", target.label, "token", zts.String(), "symbol", entry.TokenInfo.TokenSymbol)
+		}
+
+		plasma, err := z.Embedded.Plasma.Get(target.address)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		m.add("nomctl_plasma_current", "Current plasma of an address", "gauge", float64(plasma.CurrentPlasma), "address", address, "label", target.label)
+		m.add("nomctl_plasma_max", "Max plasma of an address", "gauge", float64(plasma.MaxPlasma), "address", address, "label", target.label)
+		m.add("nomctl_plasma_fused_qsr", "QSR fused for an address", "gauge", amountFloat(plasma.QsrAmount, QsrDecimals), "address", address, "label", target.label)
+
+		stakes, err := z.Embedded.Stake.GetEntriesByAddress(target.address, 0, rpcMaxPageSize)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		m.add("nomctl_stake_znn", "ZNN staked by an address", "gauge", amountFloat(stakes.TotalAmount, ZnnDecimals), "address", address, "label", target.label)
+
+		for _, source := range []struct {
+			name string
+			get  func(types.Address) (*definition.RewardDeposit, error)
+		}{
+			{"pillar", z.Embedded.Pillar.GetUncollectedReward},
+			{"stake", z.Embedded.Stake.GetUncollectedReward},
+			{"sentinel", z.Embedded.Sentinel.GetUncollectedReward},
+		} {
+			reward, err := source.get(target.address)
+			if err != nil {
+				return nil, time.Time{}, err
+			}
+			m.add("nomctl_uncollected_reward", "Uncollected rewards of an address", "gauge", amountFloat(reward.Znn, ZnnDecimals),
+				"address", address, "label", target.label, "source", source.name, "token", "ZNN")
+			m.add("nomctl_uncollected_reward", "Uncollected rewards of an address", "gauge", amountFloat(reward.Qsr, QsrDecimals),
+				"address", address, "label", target.label, "source", source.name, "token", "QSR")
+		}
+	}
+
+	pillars, err := z.Embedded.Pillar.GetAll(0, rpcMaxPageSize)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	for _, p := range pillars.List {
+		if len(e.pillars) > 0 && !e.pillars[p.Name] {
+			continue
+		}
+		m.add("nomctl_pillar_rank", "Rank of a pillar, starting at 1", "gauge", float64(p.Rank+1), "pillar", p.Name)
+		m.add("nomctl_pillar_weight", "Delegated weight of a pillar in ZNN", "gauge", amountFloat(p.Weight, ZnnDecimals), "pillar", p.Name)
+		if p.CurrentStats != nil {
+			m.add("nomctl_pillar_produced_momentums", "Momentums produced by a pillar in the current epoch", "gauge", float64(p.CurrentStats.ProducedMomentums), "pillar", p.Name)
+			m.add("nomctl_pillar_expected_momentums", "Momentums expected from a pillar in the current epoch", "gauge", float64(p.CurrentStats.ExpectedMomentums), "pillar", p.Name)
+		}
+	}
+	return m, frontier, nil
+}
+
+// run polls every interval. z is kept after a failed poll, the client drops
+// the failed connection and the next poll dials the next healthy endpoint.
+func (e *exporter) run(interval time.Duration) {
+	var z *zdk.Zdk
+	for {
+		var err error
+		if z == nil {
+			z, err = connect(url, chainId)
+		}
+		if err == nil {
+			var metrics *exporterMetrics
+			var frontier time.Time
+			if metrics, frontier, err = e.poll(z); err == nil {
+				e.mu.Lock()
+				e.metrics, e.frontier, e.up, e.lastPoll = metrics, frontier, true, time.Now()
+				e.mu.Unlock()
+			}
+		}
+		if err != nil {
+			log.Println("Error polling:", err)
+			e.mu.Lock()
+			e.up = false
+			e.mu.Unlock()
+		}
+		time.Sleep(interval)
+	}
+}
+
+// ServeHTTP writes the metrics of the last successful poll. A failed poll
+// keeps them and sets nomctl_up to 0.
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	status := newExporterMetrics()
+	up := 0.0
+	if e.up {
+		up = 1
+	}
+	status.add("nomctl_up", "Whether the last poll of the node succeeded", "gauge", up)
+	if !e.lastPoll.IsZero() {
+		status.add("nomctl_last_poll_timestamp_seconds", "Time of the last successful poll", "gauge", float64(e.lastPoll.Unix()))
+		status.add("nomctl_frontier_age_seconds", "Age of the frontier momentum of the last successful poll", "gauge", time.Since(e.frontier).Seconds())
+	}
+	status.write(w)
+	if e.metrics != nil {
+		e.metrics.write(w)
+	}
+}
+
+var exporterCommand = cli.Command{
+	Name:  "exporter",
+	Usage: "Exposes balances, plasma, rewards and pillar stats as Prometheus metrics",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Usage: "Address of the metrics server",
+			Value: ":9100",
+		},
+		&cli.StringFlag{
+			Name:  "config",
+			Usage: "Config file with the interval, addresses and pillars to export, defaults to ~/.nomctl/config.json",
+		},
+		&cli.StringFlag{
+			Name:        "url",
+			Aliases:     []string{"u"},
+			Usage:       "Provide a websocket znnd connection URL with a port, a comma separated list fails over to the next healthy one",
+			Value:       "ws://127.0.0.1:35998",
+			Destination: &url,
+		},
+		&cli.StringFlag{
+			Name:    "chainId",
+			Aliases: []string{"n"},
+			Usage:   "Specify the chain idendtifier to use, auto uses the one of the node",
+			Value:   "1",
+		},
+		&cli.DurationFlag{
+			Name:        "dial-timeout",
+			Usage:       "Timeout to connect to a node",
+			Value:       defaultDialTimeout,
+			Destination: &dialTimeout,
+		},
+		&cli.DurationFlag{
+			Name:        "request-timeout",
+			Usage:       "Timeout of a request to a node",
+			Value:       defaultRequestTimeout,
+			Destination: &requestTimeout,
+		},
+		&cli.IntFlag{
+			Name:        "retries",
+			Usage:       "Retries with backoff when no node is reachable",
+			Value:       defaultConnectRetries,
+			Destination: &connectRetries,
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 0 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("exporter [--listen :9100] [--config path]")
+			return nil
+		}
+		if err := applyChainId(cCtx); err != nil {
+			return err
+		}
+		path := cCtx.String("config")
+		if path == "" {
+			path = configPath()
+		}
+		config, err := readConfig(path)
+		if err != nil {
+			fmt.Println("Error reading config:", err)
+			return err
+		}
+
+		interval := defaultExporterInterval
+		if config.Exporter.Interval != "" {
+			if interval, err = time.ParseDuration(config.Exporter.Interval); err != nil || interval <= 0 {
+				err = fmt.Errorf("invalid exporter interval %q", config.Exporter.Interval)
+				fmt.Println("Error:", err)
+				return err
+			}
+		}
+		e := &exporter{pillars: make(map[string]bool)}
+		for _, s := range config.Exporter.Addresses {
+			address, err := parseAddress(s)
+			if err != nil {
+				fmt.Println("Error bad address:", err)
+				return err
+			}
+			target := exporterTarget{address: address}
+			if strings.HasPrefix(s, "@") {
+				target.label = s[1:]
+			}
+			e.targets = append(e.targets, target)
+		}
+		for _, name := range config.Exporter.Pillars {
+			e.pillars[name] = true
+		}
+
+		go e.run(interval)
+		http.Handle("/metrics", e)
+		fmt.Printf("Exporting %d address(es) every %v on http://%s/metrics\n", len(e.targets), interval, cCtx.String("listen"))
+		return http.ListenAndServe(cCtx.String("listen"), nil)
+	},
+}
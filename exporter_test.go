@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestExporterMetricsWrite(t *testing.T) {
+	m := newExporterMetrics()
+	m.add("nomctl_up", "Whether the node is up", "gauge", 1)
+	m.add("nomctl_balance", "Balance", "gauge", 1.5, "address", "z1q", "label", `back\slash "quoted"`+"\nnew line")
+	m.add("nomctl_pillar_rank", "Rank", "gauge", 3, "pillar", "Pillar")
+	m.add("nomctl_balance", "Balance", "gauge", 0, "address", "z1q", "label", "")
+
+	var b strings.Builder
+	m.write(&b)
+	want := `# HELP nomctl_up Whether the node is up
+# TYPE nomctl_up gauge
+nomctl_up 1
+# HELP nomctl_balance Balance
+# TYPE nomctl_balance gauge
+nomctl_balance{address="z1q",label="back\\slash \"quoted\"\nnew line"} 1.5
+nomctl_balance{address="z1q",label=""} 0
+# HELP nomctl_pillar_rank Rank
+# TYPE nomctl_pillar_rank gauge
+nomctl_pillar_rank{pillar="Pillar"} 3
+`
+	if got := b.String(); got != want {
+		t.Errorf("write =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAmountFloat(t *testing.T) {
+	tests := []struct {
+		amount   *big.Int
+		decimals uint8
+		want     float64
+	}{
+		{nil, 8, 0},
+		{big.NewInt(0), 8, 0},
+		{big.NewInt(150000000), 8, 1.5},
+		{big.NewInt(1), 8, 0.00000001},
+		{big.NewInt(42), 0, 42},
+	}
+	for _, tt := range tests {
+		if got := amountFloat(tt.amount, tt.decimals); got != tt.want {
+			t.Errorf("amountFloat(%v, %d) = %v, want %v", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
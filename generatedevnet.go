@@ -1,9 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -11,14 +15,11 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/log"
-	"github.com/tyler-smith/go-bip39"
 	"github.com/urfave/cli/v2"
 	"github.com/zenon-network/go-zenon/chain/genesis"
 	"github.com/zenon-network/go-zenon/common/types"
 	"github.com/zenon-network/go-zenon/node"
 	"github.com/zenon-network/go-zenon/p2p"
-	"github.com/zenon-network/go-zenon/vm/constants"
 	"github.com/zenon-network/go-zenon/vm/embedded/definition"
 	"github.com/zenon-network/go-zenon/wallet"
 )
@@ -47,25 +48,128 @@ var (
 
 	GenesisFusionFlag = cli.StringSliceFlag{
 		Name:  "genesis-fusion",
-		Usage: "<address>/<QsrAmount>",
+		Usage: "<address>/<QsrAmount>[/<beneficiary>[/<expirationHeight>]] an address can have multiple fusions",
+	}
+
+	GenesisDelegationFlag = cli.StringSliceFlag{
+		Name:  "genesis-delegation",
+		Usage: "<address>/<pillarName> the weight is the ZNN balance of the address",
 	}
 
-	// TODO
 	SporkAddressFlag = cli.StringFlag{
 		Name:  "spork-address",
-		Usage: "<address>",
+		Usage: "<address> allowed to create and activate sporks, defaults to the local pillar",
 	}
 
-	// TODO
-	GenesisSporkFlag = cli.StringSliceFlag{
+	GenesisSporkFlag = cli.GenericFlag{
 		Name:  "genesis-spork",
-		Usage: "<hashId>,<activationStatus: true,false>",
+		Usage: "<hashId|name>,<activationStatus: true,false>[,<enforcementHeight>] names: az, htlc, bridge-liq, hyperqube-no-pillar-reg",
+		Value: new(rawSliceValue),
 	}
 
 	GenesisEZFlag = cli.BoolFlag{
 		Name: "ez",
 	}
 
+	PillarsFlag = cli.IntFlag{
+		Name:  "pillars",
+		Usage: "Number of pillars, each with its own producer keyStore",
+		Value: 1,
+	}
+
+	NodesFlag = cli.IntFlag{
+		Name:  "nodes",
+		Usage: "Number of nodes, at least the number of pillars. Defaults to the number of pillars",
+	}
+
+	ChainIdFlag = cli.Uint64Flag{
+		Name:  "chain-id",
+		Usage: "Chain identifier of the devnet, defaults to 321",
+	}
+
+	ExtraDataFlag = cli.StringFlag{
+		Name:  "extra-data",
+		Usage: "Genesis extra data, a HYPERQUBE header must be in the format HYPERQUBE <name> UNIFORM <blockTime>",
+	}
+
+	GenesisTimestampFlag = cli.StringFlag{
+		Name:  "genesis-timestamp",
+		Usage: "Genesis timestamp as unix seconds, RFC 3339 or an offset from now like -1h, defaults to now",
+	}
+
+	SeedFlag = cli.StringFlag{
+		Name:  "seed",
+		Usage: "Derive all keys and the genesis timestamp from this seed to generate identical devnets",
+	}
+
+	ProducerPasswordFileFlag = cli.StringFlag{
+		Name:  "producer-password-file",
+		Usage: "File containing the password for all producer keyStores, defaults to a random password per producer",
+	}
+
+	SecretsPassphraseFlag = cli.StringFlag{
+		Name:  "secrets-passphrase",
+		Usage: "Write the producer mnemonics and passwords to an encrypted secrets.json, read it with devnet secrets",
+	}
+
+	AdvertiseIPFlag = cli.StringFlag{
+		Name:    "advertise-ip",
+		Aliases: []string{"listen-ip"},
+		Usage:   "IP address other nodes use to reach the devnet, used in the seeders. The nodes listen on all interfaces.",
+		Value:   "127.0.0.1",
+	}
+
+	P2PPortFlag = cli.IntFlag{
+		Name:  "p2p-port",
+		Usage: "P2P port of the first node, further nodes use +10 per node",
+		Value: p2p.DefaultListenPort,
+	}
+
+	RPCPortsFlag = cli.StringFlag{
+		Name:  "rpc-ports",
+		Usage: "<httpPort>,<wsPort> of the first node, further nodes use +10 per node",
+	}
+
+	JoinFlag = cli.StringFlag{
+		Name:  "join",
+		Usage: "Path to the seeders.json of an existing devnet to generate non-producing nodes for it",
+	}
+
+	ProfileFlag = cli.StringFlag{
+		Name:  "profile",
+		Usage: "Chain profile nom, hyperqube or a profile yaml file, defaults to hyperqube with --hyperqube and nom otherwise",
+	}
+
+	SpecFileFlag = cli.StringFlag{
+		Name:  "spec",
+		Usage: "Path to a devnet.yaml genesis spec, genesis flags are applied on top of it",
+	}
+
+	EmitFlag = cli.StringSliceFlag{
+		Name:  "emit",
+		Usage: "compose or systemd, also write a docker-compose.yml or systemd units for the nodes. Can be repeated",
+	}
+
+	ImageFlag = cli.StringFlag{
+		Name:  "image",
+		Usage: "Docker image of the nodes for --emit compose, defaults to znnd or hqzd with --hyperqube",
+	}
+
+	BinaryFlag = cli.StringFlag{
+		Name:  "binary",
+		Usage: "Absolute path of the node binary for --emit systemd, defaults to /usr/local/bin/znnd or hqzd with --hyperqube",
+	}
+
+	ForceFlag = cli.BoolFlag{
+		Name:  "force",
+		Usage: "Move an existing data path to a backup and generate a new devnet in its place",
+	}
+
+	YesFlag = cli.BoolFlag{
+		Name:  "yes",
+		Usage: "Do not ask for confirmation",
+	}
+
 	devnetCommand = cli.Command{
 		Action:    devnetAction,
 		Name:      "generate-devnet",
@@ -79,14 +183,34 @@ var (
 			&GenesisFileFlag,
 			&GenesisBlockFlag,
 			&GenesisFusionFlag,
+			&GenesisDelegationFlag,
 			&SporkAddressFlag,
 			&GenesisSporkFlag,
 			&GenesisEZFlag,
+			&SpecFileFlag,
+			&ProfileFlag,
+			&ChainIdFlag,
+			&ExtraDataFlag,
+			&GenesisTimestampFlag,
+			&PillarsFlag,
+			&NodesFlag,
+			&SeedFlag,
+			&ProducerPasswordFileFlag,
+			&SecretsPassphraseFlag,
+			&AdvertiseIPFlag,
+			&P2PPortFlag,
+			&RPCPortsFlag,
+			&JoinFlag,
+			&EmitFlag,
+			&ImageFlag,
+			&BinaryFlag,
+			&ForceFlag,
+			&YesFlag,
 		},
 	}
 )
 
-func devnetAction(ctx *cli.Context) error {
+func devnetAction(ctx *cli.Context) (err error) {
 
 	cfg := node.DefaultNodeConfig
 
@@ -108,113 +232,379 @@ func devnetAction(ctx *cli.Context) error {
 	if err := validateDevnetFlags(ctx); err != nil {
 		return err
 	}
+	spec, err := loadDevnetSpec(ctx)
+	if err != nil {
+		return err
+	}
+	profile, err := loadChainProfile(ctx.String(ProfileFlag.Name))
+	if err != nil {
+		return err
+	}
+	entropy := newDevnetEntropy(ctx.String(SeedFlag.Name))
+	if entropy.seeded() && spec.Timestamp == "" {
+		timestamp, err := entropy.timestamp()
+		if err != nil {
+			return err
+		}
+		spec.Timestamp = strconv.FormatInt(timestamp, 10)
+	}
+
+	var join *devnetSeeders
+	if file := ctx.String(JoinFlag.Name); ctx.IsSet(JoinFlag.Name) && len(file) > 0 {
+		if join, err = readDevnetSeeders(file); err != nil {
+			return err
+		}
+	}
+
+	options := devnetNodeOptions{
+		pillars: ctx.Int(PillarsFlag.Name),
+		ip:      ctx.String(AdvertiseIPFlag.Name),
+		entropy: entropy,
+	}
+	options.count = options.pillars
+	if join != nil {
+		options.pillars = 0
+		options.count = 1
+	}
+	if ctx.IsSet(NodesFlag.Name) {
+		options.count = ctx.Int(NodesFlag.Name)
+	}
+
+	cfg.Net.ListenPort = ctx.Int(P2PPortFlag.Name)
+	if ctx.IsSet(RPCPortsFlag.Name) {
+		cfg.RPC.HTTPPort, cfg.RPC.WSPort, _ = parseRPCPorts(ctx.String(RPCPortsFlag.Name))
+	}
 
 	// 2: Make dir paths absolute
 	if err := cfg.MakePathsAbsolute(); err != nil {
 		return err
 	}
+	if cfg.GenesisFile == "" {
+		cfg.GenesisFile = filepath.Join(cfg.DataPath, "genesis.json")
+	}
 
-	// 3: Check/Create dirs
-	if err := checkCreatePaths(&cfg); err != nil {
-		return err
+	if file := ctx.String(ProducerPasswordFileFlag.Name); ctx.IsSet(ProducerPasswordFileFlag.Name) && len(file) > 0 {
+		if options.password, err = readProducerPassword(file); err != nil {
+			return err
+		}
 	}
 
-	// 4: Generate Producer,
-	if err := createDevProducer(&cfg); err != nil {
+	// 3: Check/Create dirs, with --force an existing data path is moved aside
+	var backup string
+	if ctx.Bool(ForceFlag.Name) {
+		if backup, err = backupDataPath(cfg.DataPath, ctx.Bool(YesFlag.Name)); err != nil {
+			return err
+		}
+	}
+	// remove everything that was created if the devnet is incomplete and
+	// restore the backup
+	var created []string
+	defer func() {
+		if err != nil {
+			for _, path := range created {
+				_ = os.RemoveAll(path)
+			}
+			if backup != "" {
+				_ = os.Rename(backup, cfg.DataPath)
+			}
+		}
+	}()
+	if created, err = checkCreatePaths(&cfg); err != nil {
 		return err
 	}
 
-	// 5. Generate NetConfig
-	// TODO add flag for IP address to generate seeders to share with others
-	if err := createDevNet(&cfg); err != nil {
+	// 4: Generate nodes with producers and NetConfig
+	nodes, err := createDevNodes(&cfg, options)
+	if err != nil {
 		return err
 	}
 
-	// 6. Generate Genesis Config
-	if hyperqube {
-		if err := createHQZDevGenesis(ctx, &cfg); err != nil {
+	// 5. Generate Genesis Config or use the one of the joined devnet
+	var chainIdentifier uint64
+	var genesisData []byte
+	var gen *genesis.GenesisConfig
+	if join != nil {
+		chainIdentifier = join.ChainId
+		var buf bytes.Buffer
+		if err = json.Indent(&buf, join.Genesis, "", " "); err != nil {
+			return err
+		}
+		genesisData = buf.Bytes()
+		gen = new(genesis.GenesisConfig)
+		if err = json.Unmarshal(join.Genesis, gen); err != nil {
 			return err
 		}
+		for _, n := range nodes {
+			n.cfg.Net.Seeders = append(n.cfg.Net.Seeders, join.Seeders...)
+		}
 	} else {
-		if err := createDevGenesis(ctx, &cfg); err != nil {
+		if gen, err = createDevGenesis(profile, spec, devnetProducers(nodes)); err != nil {
+			return err
+		}
+		if err = validateGenesisSupply(gen); err != nil {
+			return err
+		}
+		chainIdentifier = gen.ChainIdentifier
+		if genesisData, err = json.MarshalIndent(gen, "", " "); err != nil {
 			return err
 		}
 	}
+	if err = os.WriteFile(cfg.GenesisFile, genesisData, 0644); err != nil {
+		return err
+	}
+	fmt.Println("Genesis written to", cfg.GenesisFile)
+	printGenesisReport(gen)
+	fmt.Println()
 
-	// write config
-	configPath := filepath.Join(cfg.DataPath, "config.json")
-	file, _ := json.MarshalIndent(cfg, "", " ")
-	_ = os.WriteFile(configPath, file, 0700)
+	// 6. write configs, manifest and secrets
+	for _, n := range nodes {
+		dialSeeders(&n.cfg)
+		// config.json contains the producer password
+		if err = writeJSONFile(filepath.Join(n.cfg.DataPath, "config.json"), n.cfg, 0600); err != nil {
+			return err
+		}
+	}
+	if err = writeDevnetManifest(cfg.DataPath, chainIdentifier, cfg.GenesisFile, nodes); err != nil {
+		return err
+	}
+	seeders, err := writeDevnetSeeders(cfg.DataPath, chainIdentifier, genesisData, nodes, join)
+	if err != nil {
+		return err
+	}
+	if passphrase := ctx.String(SecretsPassphraseFlag.Name); ctx.IsSet(SecretsPassphraseFlag.Name) {
+		if err = writeDevnetSecrets(filepath.Join(cfg.DataPath, devnetSecretsFile), devnetNodeSecrets(nodes), passphrase); err != nil {
+			return err
+		}
+	}
 
+	for _, emit := range ctx.StringSlice(EmitFlag.Name) {
+		var written []string
+		switch emit {
+		case "compose":
+			image := ctx.String(ImageFlag.Name)
+			if image == "" {
+				image = devnetNodeBinary()
+			}
+			var path string
+			if path, err = writeDevnetCompose(cfg.DataPath, image, nodes); err != nil {
+				return err
+			}
+			written = []string{path}
+		case "systemd":
+			binary := ctx.String(BinaryFlag.Name)
+			if binary == "" {
+				binary = filepath.Join("/usr/local/bin", devnetNodeBinary())
+			}
+			if written, err = writeDevnetSystemd(cfg.DataPath, binary, chainIdentifier, nodes); err != nil {
+				return err
+			}
+		}
+		for _, path := range written {
+			fmt.Println("Written", path)
+		}
+	}
+
+	fmt.Println("Seeders written to", filepath.Join(cfg.DataPath, devnetSeedersFile))
+	for _, seeder := range seeders {
+		fmt.Println(" ", seeder)
+	}
 	return nil
 }
 
-func checkCreatePaths(cfg *node.Config) error {
-	// Abort if datapath already exists
-	if _, err := os.Stat(cfg.DataPath); err == nil {
-		return errors.New("datapath already exists")
+// parseRPCPorts parses <httpPort>,<wsPort>
+func parseRPCPorts(s string) (int, int, error) {
+	ss := strings.Split(s, ",")
+	if len(ss) != 2 {
+		return 0, 0, errors.New("rpc-ports must be in the format --rpc-ports=<httpPort>,<wsPort>")
 	}
-	if err := os.MkdirAll(cfg.DataPath, 0700); err != nil {
-		return err
+	httpPort, err := strconv.Atoi(ss[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	wsPort, err := strconv.Atoi(ss[1])
+	if err != nil {
+		return 0, 0, err
 	}
-	if err := os.MkdirAll(cfg.WalletPath, 0700); err != nil {
+	return httpPort, wsPort, nil
+}
+
+func writeJSONFile(path string, v interface{}, perm os.FileMode) error {
+	data, err := json.MarshalIndent(v, "", " ")
+	if err != nil {
 		return err
 	}
-	return nil
+	return os.WriteFile(path, data, perm)
 }
 
-func createDevProducer(cfg *node.Config) error {
-	entropy, _ := bip39.NewEntropy(256)
-	mnemonic, _ := bip39.NewMnemonic(entropy)
+// backupDataPath moves an existing data path to <dataPath>.backup-<time> after
+// a confirmation. It refuses to move a devnet with running nodes.
+func backupDataPath(dataPath string, yes bool) (string, error) {
+	if _, err := os.Stat(dataPath); os.IsNotExist(err) {
+		return "", nil
+	}
+	if manifest, err := readDevnetManifest(dataPath); err == nil {
+		for _, n := range manifest.Nodes {
+			if devnetProcessRunning(n.PID, n.DataPath) {
+				return "", fmt.Errorf("%s is running with pid %d, stop the devnet first", n.Name, n.PID)
+			}
+		}
+	}
+	backup := dataPath + ".backup-" + time.Now().Format("20060102-150405")
+	if _, err := os.Stat(backup); err == nil {
+		return "", errors.New(backup + " already exists")
+	}
+	if !yes {
+		ok, err := confirm(fmt.Sprintf("%s exists, move it to %s and generate a new devnet?", dataPath, backup))
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", errors.New("aborted")
+		}
+	}
+	if err := os.Rename(dataPath, backup); err != nil {
+		return "", err
+	}
+	fmt.Println("Moved", dataPath, "to", backup)
+	return backup, nil
+}
 
-	ks := &wallet.KeyStore{
-		Entropy:  entropy,
-		Seed:     bip39.NewSeed(mnemonic, ""),
-		Mnemonic: mnemonic,
+// checkCreatePaths returns the paths created for the devnet, these are
+// removed again if the generation fails
+func checkCreatePaths(cfg *node.Config) ([]string, error) {
+	// Abort if datapath already exists
+	if _, err := os.Stat(cfg.DataPath); err == nil {
+		return nil, errors.New("datapath already exists, use --force to replace it")
+	}
+	created := []string{cfg.DataPath}
+	for _, path := range []string{cfg.WalletPath, cfg.GenesisFile} {
+		if _, err := os.Stat(path); os.IsNotExist(err) && !strings.HasPrefix(path, cfg.DataPath+string(filepath.Separator)) {
+			created = append(created, path)
+		}
+	}
+	if err := os.MkdirAll(cfg.DataPath, 0700); err != nil {
+		return nil, err
 	}
-	_, kp, _ := ks.DeriveForIndexPath(0)
-	ks.BaseAddress = kp.Address
+	return created, nil
+}
 
-	// TODO make this random
-	password := "Don'tTrust.Verify"
-	kf, _ := ks.Encrypt(password)
-	kf.Path = filepath.Join(cfg.WalletPath, ks.BaseAddress.String())
-	kf.Write()
+func createDevProducer(cfg *node.Config, entropy []byte, password string) (*wallet.KeyStore, error) {
+	ks, err := newKeyStoreFromEntropy(entropy)
+	if err != nil {
+		return nil, err
+	}
+	keyFilePath, err := writeKeyStore(ks, password, cfg.WalletPath, ks.BaseAddress.String())
+	if err != nil {
+		return nil, err
+	}
 
-	producer := node.ProducerConfig{
-		Address:     kp.Address.String(),
+	// znnd has no password prompt, the password has to be in config.json
+	cfg.Producer = &node.ProducerConfig{
+		Address:     ks.BaseAddress.String(),
 		Index:       0,
-		KeyFilePath: kf.Path,
+		KeyFilePath: keyFilePath,
 		Password:    password,
 	}
-
-	cfg.Producer = &producer
-
-	return nil
+	return ks, nil
 }
 
-func createDevNet(cfg *node.Config) error {
-	// generate network key
-	// ask for ip address via flag??
-
-	privateKeyFile := filepath.Join(cfg.DataPath, p2p.DefaultNetPrivateKeyFile)
-
-	key, err := crypto.GenerateKey()
+// readProducerPassword reads the first line of --producer-password-file
+func readProducerPassword(path string) (string, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Crit("Failed to generate node key", "reason", err)
+		return "", err
+	}
+	password := strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r")
+	if len(password) == 0 {
+		return "", errors.New("producer password file is empty")
 	}
+	return password, nil
+}
+
+func createDevNet(cfg *node.Config, key *ecdsa.PrivateKey) error {
+	privateKeyFile := filepath.Join(cfg.DataPath, p2p.DefaultNetPrivateKeyFile)
 
 	if err := crypto.SaveECDSA(privateKeyFile, key); err != nil {
-		log.Error("Failed to persist node key", "reason", err)
+		return err
 	}
 
 	cfg.Net.MinPeers = 0
-	cfg.Net.MinConnectedPeers = 0
 	cfg.Net.Seeders = []string{}
 	return nil
 }
 
 func validateDevnetFlags(ctx *cli.Context) error {
+	pillars := ctx.Int(PillarsFlag.Name)
+	if pillars < 1 {
+		return errors.New("pillars must be at least 1")
+	}
+	if ctx.IsSet(NodesFlag.Name) {
+		nodes := ctx.Int(NodesFlag.Name)
+		if nodes < pillars {
+			return errors.New("nodes must be at least the number of pillars")
+		}
+	}
+	if ctx.Int(NodesFlag.Name) > 1 || pillars > 1 {
+		if ctx.IsSet(WalletDirFlag.Name) {
+			return errors.New("wallet flag cannot be used with multiple nodes, every node has its own wallet")
+		}
+	}
+
+	if ctx.IsSet(JoinFlag.Name) {
+		for _, flag := range []string{SpecFileFlag.Name, ProfileFlag.Name, GenesisEZFlag.Name, GenesisBlockFlag.Name, GenesisFusionFlag.Name, GenesisDelegationFlag.Name,
+			GenesisSporkFlag.Name, SporkAddressFlag.Name, ChainIdFlag.Name, ExtraDataFlag.Name, GenesisTimestampFlag.Name,
+			PillarsFlag.Name, ProducerPasswordFileFlag.Name} {
+			if ctx.IsSet(flag) {
+				return errors.New(flag + " flag cannot be used with join, the genesis of the joined devnet is used")
+			}
+		}
+	}
+
+	ip := net.ParseIP(ctx.String(AdvertiseIPFlag.Name))
+	if ip == nil {
+		return errors.New("advertise-ip must be an IP address")
+	}
+	p2pPort := ctx.Int(P2PPortFlag.Name)
+	httpPort, wsPort := p2p.DefaultHTTPPort, p2p.DefaultWSPort
+	if ctx.IsSet(RPCPortsFlag.Name) {
+		var err error
+		if httpPort, wsPort, err = parseRPCPorts(ctx.String(RPCPortsFlag.Name)); err != nil {
+			return err
+		}
+	}
+	nodes := pillars
+	if ctx.IsSet(NodesFlag.Name) {
+		nodes = ctx.Int(NodesFlag.Name)
+	}
+	ports := make(map[int]bool)
+	for i := 0; i < nodes; i++ {
+		for _, port := range []int{p2pPort, httpPort, wsPort} {
+			port += i * devnetPortOffset
+			if port < 1 || port > 65535 {
+				return fmt.Errorf("port %d is out of range", port)
+			}
+			if ports[port] {
+				return fmt.Errorf("port %d is used more than once, every node uses its ports +%d", port, devnetPortOffset)
+			}
+			ports[port] = true
+		}
+	}
+
+	for _, emit := range ctx.StringSlice(EmitFlag.Name) {
+		switch emit {
+		case "compose":
+			if nodes > 1 && ip.IsLoopback() {
+				return errors.New("containers cannot reach each other on a loopback advertise-ip, set --advertise-ip to an address of the docker host")
+			}
+		case "systemd":
+			if binary := ctx.String(BinaryFlag.Name); ctx.IsSet(BinaryFlag.Name) && !filepath.IsAbs(binary) {
+				return errors.New("binary must be an absolute path")
+			}
+		default:
+			return errors.New("emit must be compose or systemd")
+		}
+	}
+
 	if ctx.IsSet(GenesisBlockFlag.Name) {
 		input := ctx.StringSlice(GenesisBlockFlag.Name)
 		exists := make(map[types.Address]bool)
@@ -245,7 +635,6 @@ func validateDevnetFlags(ctx *cli.Context) error {
 			if z == 0 && q == 0 {
 				return errors.New("genesis-block znn and qsr amount cannot both be 0")
 			}
-			// TODO maximum? and check for total token supply exceeds cap
 
 			if _, ok := exists[a]; ok {
 				return errors.New("genesis-block addresses must be unique")
@@ -256,12 +645,11 @@ func validateDevnetFlags(ctx *cli.Context) error {
 
 	if ctx.IsSet(GenesisFusionFlag.Name) {
 		input := ctx.StringSlice(GenesisFusionFlag.Name)
-		exists := make(map[types.Address]bool)
 		for _, s := range input {
 
 			ss := strings.Split(s, "/")
-			if len(ss) != 2 {
-				return errors.New("genesis-fusion flags must be in the format --genesis-fusion=<address>/<qsrAmount>")
+			if len(ss) < 2 || len(ss) > 4 {
+				return errors.New("genesis-fusion flags must be in the format --genesis-fusion=<address>/<qsrAmount>[/<beneficiary>[/<expirationHeight>]]")
 			}
 
 			a, err := types.ParseAddress(ss[0])
@@ -281,327 +669,184 @@ func validateDevnetFlags(ctx *cli.Context) error {
 				return errors.New("genesis-fusion amount must be between min:1 max:5000")
 			}
 
-			if _, ok := exists[a]; ok {
-				return errors.New("genesis-fusion addresses must be unique")
+			if len(ss) > 2 && ss[2] != "" {
+				b, err := types.ParseAddress(ss[2])
+				if err != nil {
+					return err
+				}
+				if types.IsEmbeddedAddress(b) {
+					return errors.New("genesis-fusion beneficiary must be a user address")
+				}
+			}
+			if len(ss) > 3 {
+				if _, err := strconv.ParseUint(ss[3], 10, 64); err != nil {
+					return errors.New("genesis-fusion expiration height must be a number")
+				}
 			}
-			exists[a] = true
 		}
 	}
-	return nil
-}
 
-func createDevGenesis(ctx *cli.Context, cfg *node.Config) error {
-	if cfg.GenesisFile == "" {
-		cfg.GenesisFile = filepath.Join(cfg.DataPath, "genesis.json")
+	if ctx.IsSet(GenesisDelegationFlag.Name) {
+		for _, s := range ctx.StringSlice(GenesisDelegationFlag.Name) {
+			ss := strings.SplitN(s, "/", 2)
+			if len(ss) != 2 {
+				return errors.New("genesis-delegation flags must be in the format --genesis-delegation=<address>/<pillarName>")
+			}
+			// the address and pillar are validated with the spec
+		}
 	}
 
-	localPillar, _ := types.ParseAddress(cfg.Producer.Address)
-
-	znnStandard := definition.TokenInfo{
-		Decimals:      8,
-		IsBurnable:    true,
-		IsMintable:    true,
-		IsUtility:     true,
-		MaxSupply:     big.NewInt(9007199254740991),
-		Owner:         types.TokenContract,
-		TokenDomain:   "biginches.club",
-		TokenName:     "tZNN",
-		TokenStandard: types.ZnnTokenStandard,
-		TokenSymbol:   "tZNN",
-		TotalSupply:   big.NewInt(78713599988800),
-	}
-	qsrStandard := definition.TokenInfo{
-		Decimals:      8,
-		IsBurnable:    true,
-		IsMintable:    true,
-		IsUtility:     true,
-		MaxSupply:     big.NewInt(9007199254740991),
-		Owner:         types.TokenContract,
-		TokenDomain:   "biginches.club",
-		TokenName:     "tQSR",
-		TokenStandard: types.QsrTokenStandard,
-		TokenSymbol:   "tQSR",
-		TotalSupply:   big.NewInt(772135999888000),
-	}
-
-	// by default activate all implemented sporks at height 0
-	// can be overriden by --genesis-spork
-	genesisSporksMap := make(map[types.Hash]bool)
-	for sporkId, status := range types.ImplementedSporksMap {
-		genesisSporksMap[sporkId] = status
-	}
-	// apply genesis sporks flag
-	genesisSporks := make([]*definition.Spork, 0)
-	genesisSporks = append(genesisSporks, &definition.Spork{
-		Id:                types.AcceleratorSpork.SporkId,
-		Name:              "az",
-		Description:       "az",
-		Activated:         true,
-		EnforcementHeight: 0,
-	})
-	genesisSporks = append(genesisSporks, &definition.Spork{
-		Id:                types.HtlcSpork.SporkId,
-		Name:              "htlc",
-		Description:       "htlc",
-		Activated:         true,
-		EnforcementHeight: 0,
-	})
-	genesisSporks = append(genesisSporks, &definition.Spork{
-		Id:                types.BridgeAndLiquiditySpork.SporkId,
-		Name:              "bridge-liq",
-		Description:       "bridge-liq",
-		Activated:         true,
-		EnforcementHeight: 0,
-	})
+	if ctx.IsSet(SporkAddressFlag.Name) {
+		a, err := types.ParseAddress(ctx.String(SporkAddressFlag.Name))
+		if err != nil {
+			return err
+		}
+		if types.IsEmbeddedAddress(a) {
+			return errors.New("spork-address flag can only be set for user addresses")
+		}
+	}
 
-	gen := genesis.GenesisConfig{
-		ChainIdentifier:     321,
-		ExtraData:           "/thank_you_bich_dao",
-		GenesisTimestampSec: time.Now().Unix(),
-		SporkAddress:        &localPillar,
+	if ctx.IsSet(GenesisSporkFlag.Name) {
+		input := rawSliceValues(ctx, GenesisSporkFlag.Name)
+		exists := make(map[types.Hash]bool)
+		for _, s := range input {
+			spork, err := parseGenesisSpork(s)
+			if err != nil {
+				return err
+			}
+			if _, ok := exists[spork.Id]; ok {
+				return errors.New("genesis-spork ids must be unique")
+			}
+			exists[spork.Id] = true
+		}
+	}
+	return nil
+}
 
-		PillarConfig: &genesis.PillarContractConfig{
-			Delegations:   []*definition.DelegationInfo{},
-			LegacyEntries: []*definition.LegacyPillarEntry{},
-			Pillars: []*definition.PillarInfo{
-				{
-					Name:                         "Local",
-					Amount:                       big.NewInt(1500000000000),
-					BlockProducingAddress:        localPillar,
-					StakeAddress:                 localPillar,
-					RewardWithdrawAddress:        localPillar,
-					PillarType:                   1,
-					RevokeTime:                   0,
-					GiveBlockRewardPercentage:    0,
-					GiveDelegateRewardPercentage: 100,
-				},
-			}},
-		TokenConfig: &genesis.TokenContractConfig{
-			Tokens: []*definition.TokenInfo{
-				&znnStandard,
-				&qsrStandard,
-			}},
-		PlasmaConfig: &genesis.PlasmaContractConfig{
-			Fusions: []*definition.FusionInfo{}},
-		SwapConfig: &genesis.SwapContractConfig{
-			Entries: []*definition.SwapAssets{}},
-		SporkConfig: &genesis.SporkConfig{
-			Sporks: genesisSporks,
-		},
-		GenesisBlocks: &genesis.GenesisBlocksConfig{
-			Blocks: []*genesis.GenesisBlockConfig{
-				{
-					Address: types.PillarContract,
-					BalanceList: map[types.ZenonTokenStandard]*big.Int{
-						types.ZnnTokenStandard: big.NewInt(1500000000000),
-					},
-				},
-				{
-					Address: types.AcceleratorContract,
-					BalanceList: map[types.ZenonTokenStandard]*big.Int{
-						types.ZnnTokenStandard: big.NewInt(77213599988800),
-						types.QsrTokenStandard: big.NewInt(772135999888000),
-					},
-				},
-			},
-		}}
+// rawSliceValue collects the values of a repeated flag without splitting them
+// at commas like cli.StringSliceFlag does
+type rawSliceValue []string
 
-	// Genesis Blocks
+func (v *rawSliceValue) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
 
-	if ctx.Bool(GenesisEZFlag.Name) {
-		znn := big.NewInt(100000 * constants.Decimals)
-		qsr := big.NewInt(500000 * constants.Decimals)
+func (v *rawSliceValue) String() string {
+	return strings.Join(*v, " ")
+}
 
-		znnStandard.TotalSupply.Add(znnStandard.TotalSupply, znn)
-		qsrStandard.TotalSupply.Add(qsrStandard.TotalSupply, qsr)
-		block := genesis.GenesisBlockConfig{
-			Address: localPillar,
-			BalanceList: map[types.ZenonTokenStandard]*big.Int{
-				types.ZnnTokenStandard: znn,
-				types.QsrTokenStandard: qsr,
-			},
-		}
-		gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, &block)
+func rawSliceValues(ctx *cli.Context, name string) []string {
+	if v, ok := ctx.Generic(name).(*rawSliceValue); ok && v != nil {
+		return *v
 	}
+	return nil
+}
 
-	if ctx.IsSet(GenesisBlockFlag.Name) {
-		input := ctx.StringSlice(GenesisBlockFlag.Name)
-		for _, s := range input {
-
-			ss := strings.Split(s, "/")
-			a, _ := types.ParseAddress(ss[0])
-			z, _ := strconv.ParseInt(ss[1], 10, 64)
-			q, _ := strconv.ParseInt(ss[2], 10, 64)
-			znn := big.NewInt(z * constants.Decimals)
-			qsr := big.NewInt(q * constants.Decimals)
-
-			znnStandard.TotalSupply.Add(znnStandard.TotalSupply, znn)
-			qsrStandard.TotalSupply.Add(qsrStandard.TotalSupply, qsr)
-			block := genesis.GenesisBlockConfig{
-				Address: a,
-				BalanceList: map[types.ZenonTokenStandard]*big.Int{
-					types.ZnnTokenStandard: znn,
-					types.QsrTokenStandard: qsr,
-				},
-			}
-			gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, &block)
+// devnetSporkNames are the friendly names accepted by --genesis-spork
+var devnetSporkNames = map[string]types.Hash{
+	"az":                      types.AcceleratorSpork.SporkId,
+	"htlc":                    types.HtlcSpork.SporkId,
+	"bridge-liq":              types.BridgeAndLiquiditySpork.SporkId,
+	"hyperqube-no-pillar-reg": types.NoPillarRegSpork.SporkId,
+}
 
+func sporkName(id types.Hash) string {
+	for name, sporkId := range devnetSporkNames {
+		if sporkId == id {
+			return name
 		}
 	}
+	return id.String()[:8]
+}
 
-	if ctx.IsSet(GenesisFusionFlag.Name) || ctx.Bool(GenesisEZFlag.Name) {
-		plasmaAddress := big.NewInt(0)
+// parseGenesisSpork parses <hashId|name>,<true|false>[,<enforcementHeight>]
+func parseGenesisSpork(s string) (*definition.Spork, error) {
+	ss := strings.Split(s, ",")
+	if len(ss) != 2 && len(ss) != 3 {
+		return nil, errors.New("genesis-spork flags must be in the format --genesis-spork=<hashId|name>,<true|false>[,<enforcementHeight>]")
+	}
 
-		qsr := big.NewInt(1000 * constants.Decimals)
-		fusion := definition.FusionInfo{
-			Owner:            localPillar,
-			Id:               types.NewHash(localPillar.Bytes()),
-			Amount:           qsr,
-			ExpirationHeight: 1,
-			Beneficiary:      localPillar,
+	id, ok := devnetSporkNames[strings.ToLower(ss[0])]
+	if !ok {
+		var err error
+		id, err = types.HexToHash(ss[0])
+		if err != nil {
+			return nil, errors.New("genesis-spork id must be a spork hash or one of az, htlc, bridge-liq, hyperqube-no-pillar-reg")
 		}
-		gen.PlasmaConfig.Fusions = append(gen.PlasmaConfig.Fusions, &fusion)
-		plasmaAddress.Add(plasmaAddress, qsr)
-		qsrStandard.TotalSupply.Add(qsrStandard.TotalSupply, qsr)
+	}
 
-		input := ctx.StringSlice(GenesisFusionFlag.Name)
-		for _, s := range input {
+	activated, err := strconv.ParseBool(ss[1])
+	if err != nil {
+		return nil, errors.New("genesis-spork activation status must be true or false")
+	}
 
-			ss := strings.Split(s, "/")
-			a, _ := types.ParseAddress(ss[0])
-			q, _ := strconv.ParseInt(ss[1], 10, 64)
-			qsr := big.NewInt(q * constants.Decimals)
-
-			qsrStandard.TotalSupply.Add(qsrStandard.TotalSupply, qsr)
-			plasmaAddress.Add(plasmaAddress, qsr)
-			fusion := definition.FusionInfo{
-				Owner:            a,
-				Id:               types.NewHash(a.Bytes()),
-				Amount:           qsr,
-				ExpirationHeight: 1,
-				Beneficiary:      a,
-			}
-			gen.PlasmaConfig.Fusions = append(gen.PlasmaConfig.Fusions, &fusion)
+	var height uint64
+	if len(ss) == 3 {
+		if !activated {
+			return nil, errors.New("genesis-spork enforcement height can only be set for activated sporks")
 		}
-		block := genesis.GenesisBlockConfig{
-			Address: types.PlasmaContract,
-			BalanceList: map[types.ZenonTokenStandard]*big.Int{
-				types.QsrTokenStandard: plasmaAddress,
-			},
+		height, err = strconv.ParseUint(ss[2], 10, 64)
+		if err != nil {
+			return nil, err
 		}
-		gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, &block)
-
 	}
 
-	file, _ := json.MarshalIndent(gen, "", " ")
-	_ = os.WriteFile(cfg.GenesisFile, file, 0644)
-
-	return nil
+	name := sporkName(id)
+	return &definition.Spork{
+		Id:                id,
+		Name:              name,
+		Description:       name,
+		Activated:         activated,
+		EnforcementHeight: height,
+	}, nil
 }
 
-func createHQZDevGenesis(ctx *cli.Context, cfg *node.Config) error {
-	if cfg.GenesisFile == "" {
-		cfg.GenesisFile = filepath.Join(cfg.DataPath, "genesis.json")
+// applyGenesisSporks overrides the default genesis sporks
+func applyGenesisSporks(overrides []*definition.Spork, sporks []*definition.Spork) []*definition.Spork {
+	for _, override := range overrides {
+		found := false
+		for _, spork := range sporks {
+			if spork.Id == override.Id {
+				spork.Activated = override.Activated
+				spork.EnforcementHeight = override.EnforcementHeight
+				found = true
+				break
+			}
+		}
+		if !found {
+			sporks = append(sporks, override)
+		}
 	}
+	return sporks
+}
+
+// createDevGenesis creates the genesis of the profile with a pillar for every
+// producer and applies the spec
+func createDevGenesis(profile *chainProfile, spec *devnetSpec, producers []types.Address) (*genesis.GenesisConfig, error) {
+	localPillar := producers[0]
+
+	znnStandard := profile.Znn.tokenInfo(big.NewInt(77213599988800))
+	qsrStandard := profile.Qsr.tokenInfo(big.NewInt(772135999888000))
 
-	localPillar, _ := types.ParseAddress(cfg.Producer.Address)
-
-	utilZ := definition.TokenInfo{
-		Decimals:      8,
-		IsBurnable:    true,
-		IsMintable:    true,
-		IsUtility:     true,
-		MaxSupply:     big.NewInt(9007199254740991),
-		Owner:         types.TokenContract,
-		TokenDomain:   "hyperqube.network",
-		TokenName:     "utilZ",
-		TokenStandard: utilZ,
-		TokenSymbol:   "utilZ",
-		TotalSupply:   big.NewInt(78713599988800),
-	}
-	utilQ := definition.TokenInfo{
-		Decimals:      8,
-		IsBurnable:    true,
-		IsMintable:    true,
-		IsUtility:     true,
-		MaxSupply:     big.NewInt(9007199254740991),
-		Owner:         types.TokenContract,
-		TokenDomain:   "hyperqube.network",
-		TokenName:     "utilQ",
-		TokenStandard: utilQ,
-		TokenSymbol:   "utilQ",
-		TotalSupply:   big.NewInt(772135999888000),
-	}
-
-	// activate sporks for accelerator-z, htlc, and deactivating pillar registration
-	// create spork for bridge but do not activate
-	// can be overriden by --genesis-spork
-
-	genesisSporksMap := make(map[types.Hash]bool)
-	for sporkId, status := range types.ImplementedSporksMap {
-		genesisSporksMap[sporkId] = status
-	}
-
-	genesisSporks := make([]*definition.Spork, 0)
-	genesisSporks = append(genesisSporks, &definition.Spork{
-		Id:                types.AcceleratorSpork.SporkId,
-		Name:              "az",
-		Description:       "az",
-		Activated:         true,
-		EnforcementHeight: 0,
-	})
-	genesisSporks = append(genesisSporks, &definition.Spork{
-		Id:                types.HtlcSpork.SporkId,
-		Name:              "htlc",
-		Description:       "htlc",
-		Activated:         true,
-		EnforcementHeight: 0,
-	})
-	genesisSporks = append(genesisSporks, &definition.Spork{
-		Id:          types.BridgeAndLiquiditySpork.SporkId,
-		Name:        "bridge-liq",
-		Description: "bridge-liq",
-		Activated:   false,
-		// confirm enforcement hieght does nothing when not activated
-		EnforcementHeight: 0,
-	})
-
-	//sha3.256(hyperqube_z spork deactivate pillar registration)
-	genesisSporks = append(genesisSporks, &definition.Spork{
-		Id:          types.HexToHashPanic("c35c80695e6f1739ce19bd9b31e4a6702335fafd643139eb73b76541be2ca9e4"),
-		Name:        "hyperqube-no-pillar-reg",
-		Description: "hyperqube-no-pillar-reg",
-		Activated:   false,
-		// confirm enforcement hieght does nothing when not activated
-		EnforcementHeight: 0,
-	})
+	// can be overriden by the spec or --genesis-spork
+	genesisSporks := profile.sporks()
+	sporkAddress := localPillar
 
 	gen := genesis.GenesisConfig{
 		ChainIdentifier:     321,
-		ExtraData:           "HYPERQUBE LOCAL UNIFORM 60",
+		ExtraData:           profile.ExtraData,
 		GenesisTimestampSec: time.Now().Unix(),
-		SporkAddress:        &localPillar,
+		SporkAddress:        &sporkAddress,
 
 		PillarConfig: &genesis.PillarContractConfig{
 			Delegations:   []*definition.DelegationInfo{},
 			LegacyEntries: []*definition.LegacyPillarEntry{},
-			Pillars: []*definition.PillarInfo{
-				{
-					Name:                         "Local",
-					Amount:                       big.NewInt(1500000000000),
-					BlockProducingAddress:        localPillar,
-					StakeAddress:                 localPillar,
-					RewardWithdrawAddress:        localPillar,
-					PillarType:                   1,
-					RevokeTime:                   0,
-					GiveBlockRewardPercentage:    0,
-					GiveDelegateRewardPercentage: 100,
-				},
-			}},
+			Pillars:       []*definition.PillarInfo{}},
 		TokenConfig: &genesis.TokenContractConfig{
 			Tokens: []*definition.TokenInfo{
-				&utilZ,
-				&utilQ,
+				znnStandard,
+				qsrStandard,
 			}},
 		PlasmaConfig: &genesis.PlasmaContractConfig{
 			Fusions: []*definition.FusionInfo{}},
@@ -615,108 +860,28 @@ func createHQZDevGenesis(ctx *cli.Context, cfg *node.Config) error {
 				{
 					Address: types.PillarContract,
 					BalanceList: map[types.ZenonTokenStandard]*big.Int{
-						utilZ.TokenStandard: big.NewInt(1500000000000),
+						znnStandard.TokenStandard: big.NewInt(0),
 					},
 				},
 				{
 					Address: types.AcceleratorContract,
 					BalanceList: map[types.ZenonTokenStandard]*big.Int{
-						utilZ.TokenStandard: big.NewInt(77213599988800),
-						utilQ.TokenStandard: big.NewInt(772135999888000),
+						znnStandard.TokenStandard: big.NewInt(77213599988800),
+						qsrStandard.TokenStandard: big.NewInt(772135999888000),
 					},
 				},
 			},
 		}}
 
-	// Genesis Blocks
-
-	if ctx.Bool(GenesisEZFlag.Name) {
-		z := big.NewInt(100000 * constants.Decimals)
-		q := big.NewInt(500000 * constants.Decimals)
-
-		utilZ.TotalSupply.Add(utilZ.TotalSupply, z)
-		utilQ.TotalSupply.Add(utilQ.TotalSupply, q)
-		block := genesis.GenesisBlockConfig{
-			Address: localPillar,
-			BalanceList: map[types.ZenonTokenStandard]*big.Int{
-				utilZ.TokenStandard: z,
-				utilQ.TokenStandard: q,
-			},
-		}
-		gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, &block)
+	if err := addDevnetPillars(&gen, producers); err != nil {
+		return nil, err
 	}
-
-	if ctx.IsSet(GenesisBlockFlag.Name) {
-		input := ctx.StringSlice(GenesisBlockFlag.Name)
-		for _, s := range input {
-
-			ss := strings.Split(s, "/")
-			a, _ := types.ParseAddress(ss[0])
-			z, _ := strconv.ParseInt(ss[1], 10, 64)
-			q, _ := strconv.ParseInt(ss[2], 10, 64)
-			zs := big.NewInt(z * constants.Decimals)
-			qs := big.NewInt(q * constants.Decimals)
-
-			utilZ.TotalSupply.Add(utilZ.TotalSupply, zs)
-			utilQ.TotalSupply.Add(utilQ.TotalSupply, qs)
-			block := genesis.GenesisBlockConfig{
-				Address: a,
-				BalanceList: map[types.ZenonTokenStandard]*big.Int{
-					utilZ.TokenStandard: zs,
-					utilQ.TokenStandard: qs,
-				},
-			}
-			gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, &block)
-
-		}
+	if err := spec.compile(&gen, localPillar); err != nil {
+		return nil, err
 	}
-
-	if ctx.IsSet(GenesisFusionFlag.Name) || ctx.Bool(GenesisEZFlag.Name) {
-		plasmaAddress := big.NewInt(0)
-
-		q := big.NewInt(1000 * constants.Decimals)
-		fusion := definition.FusionInfo{
-			Owner:            localPillar,
-			Id:               types.NewHash(localPillar.Bytes()),
-			Amount:           q,
-			ExpirationHeight: 1,
-			Beneficiary:      localPillar,
-		}
-		gen.PlasmaConfig.Fusions = append(gen.PlasmaConfig.Fusions, &fusion)
-		plasmaAddress.Add(plasmaAddress, q)
-		utilQ.TotalSupply.Add(utilQ.TotalSupply, q)
-
-		input := ctx.StringSlice(GenesisFusionFlag.Name)
-		for _, s := range input {
-
-			ss := strings.Split(s, "/")
-			a, _ := types.ParseAddress(ss[0])
-			q, _ := strconv.ParseInt(ss[1], 10, 64)
-			qsr := big.NewInt(q * constants.Decimals)
-
-			utilQ.TotalSupply.Add(utilQ.TotalSupply, qsr)
-			plasmaAddress.Add(plasmaAddress, qsr)
-			fusion := definition.FusionInfo{
-				Owner:            a,
-				Id:               types.NewHash(a.Bytes()),
-				Amount:           qsr,
-				ExpirationHeight: 1,
-				Beneficiary:      a,
-			}
-			gen.PlasmaConfig.Fusions = append(gen.PlasmaConfig.Fusions, &fusion)
-		}
-		block := genesis.GenesisBlockConfig{
-			Address: types.PlasmaContract,
-			BalanceList: map[types.ZenonTokenStandard]*big.Int{
-				utilQ.TokenStandard: plasmaAddress,
-			},
-		}
-		gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, &block)
-
+	if err := validateNativeTokens(&gen); err != nil {
+		return nil, err
 	}
 
-	file, _ := json.MarshalIndent(gen, "", " ")
-	_ = os.WriteFile(cfg.GenesisFile, file, 0644)
-
-	return nil
+	return &gen, nil
 }
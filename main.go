@@ -7,9 +7,6 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/hypercore-one/go-zdk/client"
-	"github.com/hypercore-one/go-zdk/utils"
-	"github.com/hypercore-one/go-zdk/zdk"
 	"github.com/shopspring/decimal"
 	"github.com/urfave/cli/v2"
 	"github.com/zenon-network/go-zenon/common/types"
@@ -19,6 +16,7 @@ import (
 
 var url string
 var chainId int
+var nomctlDir string
 var walletDir string
 var hyperqube bool
 
@@ -36,38 +34,30 @@ const QsrDecimals = 8
 
 const rpcMaxPageSize = 1024
 
-func connect(url string, chainId int) (*zdk.Zdk, error) {
-	// TODO Can probably get rid of chainId flag long term
-	if hyperqube {
-		// ignores chainId flag
-		rpc, err := utils.NewClientFromMeta(url)
-		if err != nil {
-			return nil, err
-		}
-		z := zdk.NewZdk(rpc)
-		return z, nil
-	} else {
-		// old logic
-		rpc, err := client.NewClient(url, client.ChainIdentifier(uint64(chainId)))
-		if err != nil {
-			return nil, err
-		}
-		z := zdk.NewZdk(rpc)
-		return z, nil
-	}
-}
-
 func formatAmount(amount *big.Int, decimals uint8) string {
 	return decimal.NewFromBigInt(amount, int32(decimals)*-1).String()
 }
 
+// parseAmount is the inverse of formatAmount and rejects excess decimals
+func parseAmount(amount string, decimals uint8) (*big.Int, error) {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, err
+	}
+	d = d.Shift(int32(decimals))
+	if !d.IsInteger() {
+		return nil, fmt.Errorf("amount %s has more than %d decimals", amount, decimals)
+	}
+	return d.BigInt(), nil
+}
+
 func main() {
 
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		log.Fatal(err)
 	}
-	nomctlDir := filepath.Join(homeDir, ".nomctl")
+	nomctlDir = filepath.Join(homeDir, ".nomctl")
 	mode := int(0700)
 	err = os.MkdirAll(nomctlDir, os.FileMode(mode))
 	if err != nil {
@@ -120,6 +110,8 @@ func main() {
 	utilsSubcommands := []*cli.Command{
 		utilsValidateAddress,
 		utilsValidateTokenStandard,
+		utilsVanity,
+		utilsKeygen,
 	}
 
 	app := &cli.App{
@@ -133,6 +125,8 @@ func main() {
 				Subcommands: utilsSubcommands,
 			},
 			&devnetCommand,
+			&devnetToolsCommand,
+			&exporterCommand,
 		},
 		Flags: []cli.Flag{
 			&HyperQubeFlag,
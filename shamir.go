@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Shamir secret sharing over GF(256) for keyStore entropy.
+//
+// The secret is extended with a digest, the first 4 bytes of sha256(secret),
+// as in SLIP-39. Every byte of the extended secret is split with its own
+// random polynomial of degree threshold-1 whose constant term is the byte.
+// Share i is the evaluation of all polynomials at x = i (1 <= i <= 255).
+// Arithmetic uses the AES field polynomial x^8 + x^4 + x^3 + x + 1. The digest
+// is only known after combining, so fewer than threshold shares reveal
+// nothing about the secret.
+//
+// A share is serialized as
+//
+//	version (1) | set id (2) | threshold (1) | index (1) | data length (1) |
+//	share data (data length) | checksum (4)
+//
+// where the checksum is the first 4 bytes of sha256 over all preceding bytes,
+// used to verify a single share. The bytes are zero padded to a multiple of
+// 11 bits and written as BIP-39 english words.
+
+const (
+	shamirVersion      = 2
+	shamirHeaderLength = 6
+	shamirDigestLength = 4
+	shamirCheckLength  = 4
+)
+
+type shamirShare struct {
+	id        uint16
+	threshold byte
+	index     byte
+	data      []byte
+}
+
+func gfMul(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 == 1 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+func gfInv(a byte) byte {
+	// a^254 = a^-1 in GF(256)
+	r := byte(1)
+	for i := 0; i < 254; i++ {
+		r = gfMul(r, a)
+	}
+	return r
+}
+
+func shamirSplit(secret []byte, threshold, shares int) ([]*shamirShare, error) {
+	if threshold < 1 || shares < threshold || shares > 255 {
+		return nil, errors.New("threshold must be at least 1 and at most shares, shares at most 255")
+	}
+	if len(secret) == 0 || len(secret)+shamirDigestLength > 255 {
+		return nil, errors.New("invalid secret length")
+	}
+
+	id := make([]byte, 2)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(secret)
+	secret = append(append([]byte{}, secret...), digest[:shamirDigestLength]...)
+
+	coefficients := make([]byte, len(secret)*(threshold-1))
+	if _, err := rand.Read(coefficients); err != nil {
+		return nil, err
+	}
+
+	result := make([]*shamirShare, shares)
+	for i := 0; i < shares; i++ {
+		x := byte(i + 1)
+		share := &shamirShare{
+			id:        uint16(id[0])<<8 | uint16(id[1]),
+			threshold: byte(threshold),
+			index:     x,
+			data:      make([]byte, len(secret)),
+		}
+		for b := range secret {
+			// Horner's method, highest coefficient first
+			y := byte(0)
+			for c := threshold - 2; c >= 0; c-- {
+				y = gfMul(y, x) ^ coefficients[b*(threshold-1)+c]
+			}
+			share.data[b] = gfMul(y, x) ^ secret[b]
+		}
+		result[i] = share
+	}
+	return result, nil
+}
+
+func shamirCombine(shares []*shamirShare) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares provided")
+	}
+	first := shares[0]
+	if len(shares) < int(first.threshold) {
+		return nil, fmt.Errorf("%d shares are required but only %d were provided", first.threshold, len(shares))
+	}
+	shares = shares[:first.threshold]
+
+	seen := make(map[byte]bool)
+	for _, s := range shares {
+		if s.id != first.id || s.threshold != first.threshold || len(s.data) != len(first.data) {
+			return nil, errors.New("shares belong to different backups")
+		}
+		if seen[s.index] {
+			return nil, fmt.Errorf("share %d was provided more than once", s.index)
+		}
+		seen[s.index] = true
+	}
+
+	secret := make([]byte, len(first.data))
+	for i, si := range shares {
+		// Lagrange basis polynomial for share i evaluated at x = 0
+		basis := byte(1)
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			basis = gfMul(basis, gfMul(sj.index, gfInv(sj.index^si.index)))
+		}
+		for b := range secret {
+			secret[b] ^= gfMul(si.data[b], basis)
+		}
+	}
+
+	if len(secret) <= shamirDigestLength {
+		return nil, errors.New("share data is too short")
+	}
+	secret, check := secret[:len(secret)-shamirDigestLength], secret[len(secret)-shamirDigestLength:]
+	digest := sha256.Sum256(secret)
+	if !bytes.Equal(digest[:shamirDigestLength], check) {
+		return nil, errors.New("recovered secret does not match its digest, the shares don't belong together")
+	}
+	return secret, nil
+}
+
+func (s *shamirShare) bytes() []byte {
+	b := []byte{shamirVersion, byte(s.id >> 8), byte(s.id), s.threshold, s.index, byte(len(s.data))}
+	b = append(b, s.data...)
+	check := sha256.Sum256(b)
+	return append(b, check[:shamirCheckLength]...)
+}
+
+// words encodes the share as BIP-39 words, 11 bits per word
+func (s *shamirShare) words() string {
+	data := s.bytes()
+	words := bip39.GetWordList()
+	n := (len(data)*8 + 10) / 11
+	result := make([]string, n)
+	for w := 0; w < n; w++ {
+		v := 0
+		for bit := w * 11; bit < w*11+11; bit++ {
+			v <<= 1
+			if bit/8 < len(data) && data[bit/8]&(0x80>>(bit%8)) != 0 {
+				v |= 1
+			}
+		}
+		result[w] = words[v]
+	}
+	return strings.Join(result, " ")
+}
+
+// parseShamirShare decodes and checksums a share without needing any other share
+func parseShamirShare(s string) (*shamirShare, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	bits := make([]bool, 0, len(fields)*11)
+	for _, w := range fields {
+		v, ok := bip39.GetWordIndex(w)
+		if !ok {
+			return nil, fmt.Errorf("unknown word %q", w)
+		}
+		for bit := 10; bit >= 0; bit-- {
+			bits = append(bits, v&(1<<bit) != 0)
+		}
+	}
+	data := make([]byte, len(bits)/8)
+	for i := range data {
+		for bit := 0; bit < 8; bit++ {
+			if bits[i*8+bit] {
+				data[i] |= 0x80 >> bit
+			}
+		}
+	}
+
+	if len(data) < shamirHeaderLength+shamirCheckLength {
+		return nil, errors.New("share is too short")
+	}
+	if data[0] != shamirVersion {
+		return nil, fmt.Errorf("unsupported share version %d", data[0])
+	}
+	length := int(data[5])
+	total := shamirHeaderLength + length + shamirCheckLength
+	if len(data) < total || (len(bits)-total*8) >= 11 {
+		return nil, errors.New("share has an invalid length")
+	}
+	check := sha256.Sum256(data[:total-shamirCheckLength])
+	if !bytes.Equal(check[:shamirCheckLength], data[total-shamirCheckLength:total]) {
+		return nil, errors.New("share checksum mismatch")
+	}
+
+	share := &shamirShare{
+		id:        uint16(data[1])<<8 | uint16(data[2]),
+		threshold: data[3],
+		index:     data[4],
+		data:      data[shamirHeaderLength : shamirHeaderLength+length],
+	}
+	if share.threshold == 0 || share.index == 0 {
+		return nil, errors.New("share has an invalid header")
+	}
+	return share, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// subsets returns every subset of size k of shares
+func subsets(shares []*shamirShare, k int) [][]*shamirShare {
+	if k == 0 {
+		return [][]*shamirShare{nil}
+	}
+	var result [][]*shamirShare
+	for i := 0; i+k <= len(shares); i++ {
+		for _, rest := range subsets(shares[i+1:], k-1) {
+			result = append(result, append([]*shamirShare{shares[i]}, rest...))
+		}
+	}
+	return result
+}
+
+func TestShamirRoundTrip(t *testing.T) {
+	tests := []struct {
+		secretLength, threshold, shares int
+	}{
+		{16, 1, 1},
+		{16, 2, 3},
+		{32, 3, 5},
+		{32, 5, 5},
+		{251, 2, 2},
+	}
+	for _, tt := range tests {
+		secret := bytes.Repeat([]byte{0xa5, 0x01, 0xff}, tt.secretLength)[:tt.secretLength]
+		shares, err := shamirSplit(secret, tt.threshold, tt.shares)
+		if err != nil {
+			t.Fatalf("shamirSplit(%d, %d, %d): %v", tt.secretLength, tt.threshold, tt.shares, err)
+		}
+		for _, subset := range subsets(shares, tt.threshold) {
+			got, err := shamirCombine(subset)
+			if err != nil {
+				t.Fatalf("shamirCombine of %d/%d shares: %v", tt.threshold, tt.shares, err)
+			}
+			if !bytes.Equal(got, secret) {
+				t.Fatalf("shamirCombine of %d/%d shares recovered %x, want %x", tt.threshold, tt.shares, got, secret)
+			}
+		}
+	}
+}
+
+func TestShamirSplitInvalid(t *testing.T) {
+	tests := []struct {
+		secretLength, threshold, shares int
+	}{
+		{16, 0, 3},
+		{16, 4, 3},
+		{16, 2, 256},
+		{0, 2, 3},
+		{252, 2, 3},
+	}
+	for _, tt := range tests {
+		if _, err := shamirSplit(make([]byte, tt.secretLength), tt.threshold, tt.shares); err == nil {
+			t.Errorf("shamirSplit(%d, %d, %d) succeeded, want an error", tt.secretLength, tt.threshold, tt.shares)
+		}
+	}
+}
+
+func TestShamirCombineInvalid(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	a, err := shamirSplit(secret, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := shamirSplit(secret, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a forged set id makes the shares of b look like part of a
+	forged := *b[2]
+	forged.id = a[0].id
+	corrupted := *a[2]
+	corrupted.data = append([]byte{}, a[2].data...)
+	corrupted.data[0] ^= 1
+
+	tests := []struct {
+		name   string
+		shares []*shamirShare
+	}{
+		{"none", nil},
+		{"below threshold", a[:2]},
+		{"duplicate", []*shamirShare{a[0], a[1], a[1]}},
+		{"different backups", []*shamirShare{a[0], a[1], b[2]}},
+		{"forged set id", []*shamirShare{a[0], a[1], &forged}},
+		{"corrupted data", []*shamirShare{a[0], a[1], &corrupted}},
+	}
+	for _, tt := range tests {
+		if got, err := shamirCombine(tt.shares); err == nil {
+			t.Errorf("%s: shamirCombine recovered %x, want an error", tt.name, got)
+		}
+	}
+}
+
+func TestShamirShareWords(t *testing.T) {
+	shares, err := shamirSplit([]byte("0123456789abcdef0123456789abcdef"), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, share := range shares {
+		words := share.words()
+		parsed, err := parseShamirShare(strings.ToUpper(words))
+		if err != nil {
+			t.Fatalf("parseShamirShare(%q): %v", words, err)
+		}
+		if parsed.id != share.id || parsed.threshold != share.threshold || parsed.index != share.index || !bytes.Equal(parsed.data, share.data) {
+			t.Fatalf("parseShamirShare(%q) = %+v, want %+v", words, parsed, share)
+		}
+	}
+
+	fields := strings.Fields(shares[0].words())
+	list := bip39.GetWordList()
+	replaced := append([]string{}, fields...)
+	if replaced[8] == list[0] {
+		replaced[8] = list[1]
+	} else {
+		replaced[8] = list[0]
+	}
+	invalid := []string{
+		"",
+		strings.Join(fields[:5], " "),
+		strings.Join(replaced, " "),
+		strings.Join(append(fields, list[0], list[0]), " "),
+		strings.Join(append(fields[:len(fields)-1], "notaword"), " "),
+	}
+	for _, s := range invalid {
+		if _, err := parseShamirShare(s); err == nil {
+			t.Errorf("parseShamirShare(%q) succeeded, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tyler-smith/go-bip39"
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/wallet"
+)
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// User addresses always start with z1q followed by one of q, p, z or r, so a
+// vanity prefix is matched after this header unless it starts with z1.
+const vanityHeaderLength = 4
+
+// vanityFourthChars are the characters following z1q, the top bits of the
+// first byte after the address type are always zero
+const vanityFourthChars = "qpzr"
+
+// vanityAttempts is the expected number of addresses to derive for a match.
+// The fixed characters of a pattern starting with z1 don't count.
+func vanityAttempts(pattern string, suffix bool) float64 {
+	pattern = strings.ToLower(pattern)
+	if suffix || !strings.HasPrefix(pattern, "z1") {
+		return math.Pow(float64(len(bech32Charset)), float64(len(pattern)))
+	}
+	attempts := 1.0
+	for i, c := range strings.TrimPrefix(pattern, "z1") {
+		switch {
+		case i == 0 && c != 'q', i == 1 && !strings.ContainsRune(vanityFourthChars, c):
+			return math.Inf(1)
+		case i == 1:
+			attempts *= float64(len(vanityFourthChars))
+		case i > 1:
+			attempts *= float64(len(bech32Charset))
+		}
+	}
+	return attempts
+}
+
+type vanityResult struct {
+	ks      *wallet.KeyStore
+	index   uint32
+	address types.Address
+}
+
+func vanityMatcher(pattern string, suffix bool) (func(string) bool, error) {
+	pattern = strings.ToLower(pattern)
+	full := !suffix && strings.HasPrefix(pattern, "z1")
+	check := pattern
+	if full {
+		check = strings.TrimPrefix(pattern, "z1")
+	}
+	if len(check) == 0 {
+		return nil, errors.New("pattern cannot be empty")
+	}
+	for _, c := range check {
+		if !strings.ContainsRune(bech32Charset, c) {
+			return nil, fmt.Errorf("character %q is not part of the bech32 charset %s", c, bech32Charset)
+		}
+	}
+
+	if full && math.IsInf(vanityAttempts(pattern, false), 1) {
+		return nil, fmt.Errorf("user addresses start with z1q followed by one of %s", vanityFourthChars)
+	}
+
+	if suffix {
+		return func(a string) bool { return strings.HasSuffix(a, pattern) }, nil
+	}
+	if full {
+		return func(a string) bool { return strings.HasPrefix(a, pattern) }, nil
+	}
+	return func(a string) bool { return strings.HasPrefix(a[vanityHeaderLength:], pattern) }, nil
+}
+
+// searchVanity derives addresses until one matches. In index mode every
+// mnemonic is searched up to maxIndex before a new one is generated, which
+// avoids the expensive seed derivation for most attempts.
+func searchVanity(ctx context.Context, match func(string) bool, byIndex bool, maxIndex uint32, attempts *uint64, found chan<- vanityResult) error {
+	for {
+		entropy, err := bip39.NewEntropy(256)
+		if err != nil {
+			return err
+		}
+		ks, err := newKeyStoreFromEntropy(entropy)
+		if err != nil {
+			return err
+		}
+
+		last := uint32(0)
+		if byIndex {
+			last = maxIndex - 1
+		}
+		for i := uint32(0); i <= last; i++ {
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+			}
+
+			_, kp, err := ks.DeriveForIndexPath(i)
+			if err != nil {
+				return err
+			}
+			atomic.AddUint64(attempts, 1)
+			if match(kp.Address.String()) {
+				select {
+				case found <- vanityResult{ks: ks, index: i, address: kp.Address}:
+				case <-ctx.Done():
+				}
+				return nil
+			}
+		}
+	}
+}
+
+func formatEta(seconds float64) string {
+	if math.IsInf(seconds, 0) || math.IsNaN(seconds) || seconds > 100*365*24*3600 {
+		return "a very long time"
+	}
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+var utilsVanity = &cli.Command{
+	Name:  "vanity",
+	Usage: "prefix|suffix",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "suffix",
+			Usage: "Match the end of the address instead of the beginning",
+		},
+		&cli.BoolFlag{
+			Name:  "index",
+			Usage: "Search derived address indexes of each mnemonic instead of only the base address",
+		},
+		&cli.UintFlag{
+			Name:  "max-index",
+			Usage: "Number of address indexes searched per mnemonic with --index",
+			Value: 128,
+		},
+		&cli.IntFlag{
+			Name:  "threads",
+			Usage: "Number of parallel workers",
+			Value: runtime.NumCPU(),
+		},
+		&cli.StringFlag{
+			Name:    "passphrase",
+			Aliases: []string{"p"},
+			Usage:   "Passphrase for the new keyStore or enter it manually in a secure way",
+		},
+		&cli.StringFlag{
+			Name:  "name",
+			Usage: "Name of the new keyStore, defaults to the base address",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("vanity [--suffix] [--index] pattern")
+			return nil
+		}
+
+		suffix := cCtx.Bool("suffix")
+		match, err := vanityMatcher(cCtx.Args().Get(0), suffix)
+		if err != nil {
+			fmt.Println("Error bad pattern:", err)
+			return err
+		}
+		threads := cCtx.Int("threads")
+		if threads < 1 {
+			fmt.Println("Error! threads must be at least 1")
+			return nil
+		}
+		maxIndex := uint32(cCtx.Uint("max-index"))
+		if maxIndex < 1 || maxIndex > 128 {
+			fmt.Println("Error! max-index must be between 1 and 128")
+			return nil
+		}
+
+		passphrase := cCtx.String("passphrase")
+		if !cCtx.IsSet("passphrase") {
+			passphrase, err = promptPassphrase("Insert passphrase for the new keyStore:")
+			if err != nil {
+				return err
+			}
+		}
+
+		expected := vanityAttempts(cCtx.Args().Get(0), suffix)
+		fmt.Printf("Searching with %d worker(s), about %.0f attempts expected\n", threads, expected)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var attempts uint64
+		found := make(chan vanityResult)
+		errs := make(chan error, threads)
+		var wg sync.WaitGroup
+		for i := 0; i < threads; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := searchVanity(ctx, match, cCtx.Bool("index"), maxIndex, &attempts, found); err != nil {
+					errs <- err
+				}
+			}()
+		}
+
+		start := time.Now()
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+
+		var result vanityResult
+	search:
+		for {
+			select {
+			case result = <-found:
+				break search
+			case err := <-errs:
+				cancel()
+				wg.Wait()
+				fmt.Println("Error searching addresses:", err)
+				return err
+			case <-ticker.C:
+				n := atomic.LoadUint64(&attempts)
+				rate := float64(n) / time.Since(start).Seconds()
+				remaining := math.Max(expected-float64(n), 0) / rate
+				fmt.Printf("  %d attempts, %.0f addresses/s, expected time left %s\n", n, rate, formatEta(remaining))
+			}
+		}
+		cancel()
+		wg.Wait()
+
+		name := result.ks.BaseAddress.String()
+		if cCtx.IsSet("name") {
+			name = cCtx.String("name")
+		}
+		if _, err := createKeyStore(result.ks, passphrase, walletDir, name); err != nil {
+			fmt.Println("Error writing keyStore:", err)
+			return err
+		}
+
+		fmt.Printf("Found %s after %d attempts in %s\n", result.address, atomic.LoadUint64(&attempts), time.Since(start).Round(time.Second))
+		fmt.Println("keyStore successfully created:", name)
+		if result.index != 0 {
+			fmt.Printf("Use '--keyStore %s --index %d' to select the address\n", name, result.index)
+		}
+		return nil
+	},
+}
+
+type keygenEntry struct {
+	Mnemonic  string          `json:"mnemonic"`
+	Addresses []types.Address `json:"addresses"`
+	KeyStore  string          `json:"keyStore,omitempty"`
+}
+
+var utilsKeygen = &cli.Command{
+	Name:  "keygen",
+	Usage: "Generates mnemonics, addresses and optionally keyStores as JSON for test fixtures",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "count",
+			Usage: "Number of mnemonics to generate",
+			Value: 1,
+		},
+		&cli.IntFlag{
+			Name:  "addresses",
+			Usage: "Number of addresses to derive per mnemonic",
+			Value: 1,
+		},
+		&cli.StringFlag{
+			Name:    "passphrase",
+			Aliases: []string{"p"},
+			Usage:   "Write an encrypted keyStore for every mnemonic using this passphrase",
+		},
+		&cli.StringFlag{
+			Name:  "dir",
+			Usage: "Directory for the keyStores, defaults to the nomctl wallet directory",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 0 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("keygen [--count N] [--addresses N] [--passphrase passphrase [--dir dir]]")
+			return nil
+		}
+		count := cCtx.Int("count")
+		if count < 1 {
+			fmt.Println("Error! count must be at least 1")
+			return nil
+		}
+		addresses := cCtx.Int("addresses")
+		if addresses < 1 || addresses > 128 {
+			fmt.Println("Error! addresses must be between 1 and 128")
+			return nil
+		}
+		dir := walletDir
+		if cCtx.IsSet("dir") {
+			dir = cCtx.String("dir")
+		}
+
+		entries := make([]keygenEntry, 0, count)
+		for i := 0; i < count; i++ {
+			entropy, err := bip39.NewEntropy(256)
+			if err != nil {
+				return err
+			}
+			ks, err := newKeyStoreFromEntropy(entropy)
+			if err != nil {
+				return err
+			}
+
+			entry := keygenEntry{Mnemonic: ks.Mnemonic}
+			for j := 0; j < addresses; j++ {
+				_, kp, err := ks.DeriveForIndexPath(uint32(j))
+				if err != nil {
+					return err
+				}
+				entry.Addresses = append(entry.Addresses, kp.Address)
+			}
+			if cCtx.IsSet("passphrase") {
+				entry.KeyStore, err = createKeyStore(ks, cCtx.String("passphrase"), dir, ks.BaseAddress.String())
+				if err != nil {
+					fmt.Println("Error writing keyStore:", err)
+					return err
+				}
+			}
+			entries = append(entries, entry)
+		}
+
+		data, err := json.MarshalIndent(entries, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVanityAttempts(t *testing.T) {
+	tests := []struct {
+		pattern string
+		suffix  bool
+		want    float64
+	}{
+		{"abc", false, 32 * 32 * 32},
+		{"abc", true, 32 * 32 * 32},
+		{"z1", true, 32 * 32},
+		{"z1q", false, 1},
+		{"z1qq", false, 4},
+		{"z1qpa", false, 4 * 32},
+		{"Z1QPA", false, 4 * 32},
+		{"z1x", false, math.Inf(1)},
+		{"z1qa", false, math.Inf(1)},
+	}
+	for _, tt := range tests {
+		if got := vanityAttempts(tt.pattern, tt.suffix); got != tt.want {
+			t.Errorf("vanityAttempts(%q, %v) = %v, want %v", tt.pattern, tt.suffix, got, tt.want)
+		}
+	}
+}
+
+func TestVanityMatcher(t *testing.T) {
+	const address = "z1qqvld3gfe9qfukae0xz3yqf92qencczjk27jxp"
+	tests := []struct {
+		pattern string
+		suffix  bool
+		match   bool
+		invalid bool
+	}{
+		{"vld", false, true, false},
+		{"z1qqvld", false, true, false},
+		{"z1qpvld", false, false, false},
+		{"jxp", true, true, false},
+		{"qqv", false, false, false},
+		{"z1qa", false, false, true},
+		{"b", false, false, true},
+		{"z1", false, false, true},
+	}
+	for _, tt := range tests {
+		match, err := vanityMatcher(tt.pattern, tt.suffix)
+		if (err != nil) != tt.invalid {
+			t.Errorf("vanityMatcher(%q, %v) error = %v, want invalid %v", tt.pattern, tt.suffix, err, tt.invalid)
+			continue
+		}
+		if err == nil && match(address) != tt.match {
+			t.Errorf("vanityMatcher(%q, %v) matches %s = %v, want %v", tt.pattern, tt.suffix, address, !tt.match, tt.match)
+		}
+	}
+}
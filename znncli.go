@@ -1,7 +1,10 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -50,14 +53,34 @@ func getTokenStandard(zts string) (types.ZenonTokenStandard, error) {
 
 }
 
-func getZnnCliSigner(walletDir string, cCtx *cli.Context) (signer.Signer, error) {
+func promptPassphrase(prompt string) (string, error) {
+	fmt.Println(prompt)
+	pw, err := term.ReadPassword(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", err
+	}
+	return string(pw), nil
+}
+
+// confirm asks a yes or no question on stdin, anything but yes is a no
+func confirm(prompt string) (bool, error) {
+	fmt.Print(prompt, " [y/N] ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+func getZnnCliKeyStorePath(walletDir string, cCtx *cli.Context) (string, error) {
 
 	var keyStorePath string
 
 	// TODO use go-zdk keystore manager when available
 	files, err := os.ReadDir(walletDir)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	if len(files) == 0 {
 		fmt.Println("Error! No keystore in the default directory")
@@ -78,11 +101,19 @@ func getZnnCliSigner(walletDir string, cCtx *cli.Context) (signer.Signer, error)
 		os.Exit(1)
 	}
 
+	return keyStorePath, nil
+}
+
+func getZnnCliKeyStore(walletDir string, cCtx *cli.Context) (*wallet.KeyStore, error) {
+
+	keyStorePath, err := getZnnCliKeyStorePath(walletDir, cCtx)
+	if err != nil {
+		return nil, err
+	}
+
 	var passphrase string
 	if !cCtx.IsSet("passphrase") {
-		fmt.Println("Insert passphrase:")
-		pw, err := term.ReadPassword(int(os.Stdin.Fd()))
-		passphrase = string(pw)
+		passphrase, err = promptPassphrase("Insert passphrase:")
 		if err != nil {
 			return nil, err
 		}
@@ -103,6 +134,21 @@ func getZnnCliSigner(walletDir string, cCtx *cli.Context) (signer.Signer, error)
 		return nil, err
 	}
 
+	return ks, nil
+}
+
+// getZnnCliSigner decrypts the keyStore of a command that signs. --address is
+// watch-only and rejected instead of being ignored.
+func getZnnCliSigner(walletDir string, cCtx *cli.Context) (signer.Signer, error) {
+	if cCtx.IsSet("address") {
+		return nil, errors.New("--address is watch-only, commands that sign need a keyStore")
+	}
+
+	ks, err := getZnnCliKeyStore(walletDir, cCtx)
+	if err != nil {
+		return nil, err
+	}
+
 	_, keyPair, err := ks.DeriveForIndexPath(uint32(cCtx.Int("index")))
 	if err != nil {
 		return nil, err
@@ -113,21 +159,64 @@ func getZnnCliSigner(walletDir string, cCtx *cli.Context) (signer.Signer, error)
 
 }
 
+// getZnnCliAddress returns the address a read-only command should query.
+// An --address (or @label from the address book) is used as is. Otherwise the
+// base address stored in plain text in the keyStore is used, and the keyStore
+// is only decrypted when a non-zero --index has to be derived.
+func getZnnCliAddress(walletDir string, cCtx *cli.Context) (types.Address, error) {
+	if cCtx.IsSet("address") {
+		return parseAddress(cCtx.String("address"))
+	}
+
+	if cCtx.Int("index") == 0 {
+		keyStorePath, err := getZnnCliKeyStorePath(walletDir, cCtx)
+		if err != nil {
+			return types.ZeroAddress, err
+		}
+		kf, err := wallet.ReadKeyFile(keyStorePath)
+		if err != nil {
+			return types.ZeroAddress, err
+		}
+		return kf.BaseAddress, nil
+	}
+
+	kp, err := getZnnCliSigner(walletDir, cCtx)
+	if err != nil {
+		return types.ZeroAddress, err
+	}
+	return kp.Address(), nil
+}
+
 var znnCliSubcommands = []*cli.Command{
 	znnCliSend,
 	znnCliReceiveAll,
 	znnCliUnreceived,
 	znnCliBalance,
 	znnCliFrontierMomentum,
+	znnCliNodeInfo,
+	znnCliNodeSync,
+	znnCliNodePeers,
+	znnCliNodeHealth,
 	znnCliWalletCreateNew,
 	znnCliWalletCreateFromMnemonic,
 	znnCliWalletList,
+	znnCliWalletBackupSplit,
+	znnCliWalletBackupVerify,
+	znnCliWalletBackupCombine,
+	znnCliWalletExport,
+	znnCliWalletImport,
+	znnCliAddressBookAdd,
+	znnCliAddressBookList,
+	znnCliAddressBookRemove,
+	znnCliMessageSign,
+	znnCliMessageVerify,
 	//		znnCliWalletDeriveAddresses,
 	znnCliPlasmaList,
 	znnCliPlasmaGet,
 	znnCliPlasmaFuse,
 	znnCliPlasmaCancel,
 	znnCliPillarList,
+	znnCliPillarMonitor,
 	znnCliPillarUncollected,
 	znnCliPillarCollect,
 	znnCliPillarDelegate,
@@ -144,24 +233,72 @@ var znnCliSubcommands = []*cli.Command{
 	znnCliStakeCollect,
 }
 
+// applyDevnetManifest uses the chain identifier and the nodes of a generated
+// devnet unless --chainId or --url are set
+func applyDevnetManifest(cCtx *cli.Context) error {
+	if !cCtx.IsSet("devnet") {
+		return nil
+	}
+	manifest, err := readDevnetManifest(cCtx.String("devnet"))
+	if err != nil {
+		fmt.Println("Error reading devnet manifest:", err)
+		return err
+	}
+	if !cCtx.IsSet("chainId") {
+		chainId = int(manifest.ChainId)
+	}
+	if !cCtx.IsSet("url") && len(manifest.Nodes) > 0 {
+		url = devnetEndpoints(manifest)
+	}
+	return nil
+}
+
 var znnCliCommand = cli.Command{
 	Name:        "znn-cli",
 	Usage:       "A port of znn_cli_dart",
 	Subcommands: znnCliSubcommands,
+	Before: func(cCtx *cli.Context) error {
+		if err := applyChainId(cCtx); err != nil {
+			return err
+		}
+		return applyDevnetManifest(cCtx)
+	},
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:        "url",
 			Aliases:     []string{"u"},
-			Usage:       "Provide a websocket znnd connection URL with a port",
+			Usage:       "Provide a websocket znnd connection URL with a port, a comma separated list fails over to the next healthy one",
 			Value:       "ws://127.0.0.1:35998",
 			Destination: &url,
 		},
+		&cli.StringFlag{
+			Name:    "chainId",
+			Aliases: []string{"n"},
+			Usage:   "Specify the chain idendtifier to use, auto uses the one of the node",
+			Value:   "1",
+		},
+		&cli.DurationFlag{
+			Name:        "dial-timeout",
+			Usage:       "Timeout to connect to a node",
+			Value:       defaultDialTimeout,
+			Destination: &dialTimeout,
+		},
+		&cli.DurationFlag{
+			Name:        "request-timeout",
+			Usage:       "Timeout of a request to a node",
+			Value:       defaultRequestTimeout,
+			Destination: &requestTimeout,
+		},
 		&cli.IntFlag{
-			Name:        "chainId",
-			Aliases:     []string{"n"},
-			Usage:       "Specify the chain idendtifier to use",
-			Value:       1,
-			Destination: &chainId,
+			Name:        "retries",
+			Usage:       "Retries with backoff when no node is reachable",
+			Value:       defaultConnectRetries,
+			Destination: &connectRetries,
+		},
+		&cli.StringFlag{
+			Name:    "devnet",
+			Usage:   "Path to a generate-devnet data folder, its manifest provides the default url and chainId",
+			EnvVars: []string{"NOMCTL_DEVNET"},
 		},
 		&cli.StringFlag{
 			Name:    "passphrase",
@@ -173,6 +310,11 @@ var znnCliCommand = cli.Command{
 			Aliases: []string{"k"},
 			Usage:   "Select the local keyStore",
 		},
+		&cli.StringFlag{
+			Name:    "address",
+			Aliases: []string{"a"},
+			Usage:   "Query this address or @label from the address book instead of a keyStore, only for read-only commands",
+		},
 		&cli.IntFlag{
 			Name:    "index",
 			Aliases: []string{"i"},
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+const addressBookFileName = "addressbook.json"
+
+// addressBook maps a label to a watch-only address. Labels can be used
+// anywhere an address is accepted by prefixing them with '@'.
+type addressBook map[string]types.Address
+
+func addressBookPath() string {
+	return filepath.Join(nomctlDir, addressBookFileName)
+}
+
+func readAddressBook() (addressBook, error) {
+	book := make(addressBook)
+	data, err := os.ReadFile(addressBookPath())
+	if os.IsNotExist(err) {
+		return book, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func writeAddressBook(book addressBook) error {
+	data, err := json.MarshalIndent(book, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(addressBookPath(), data, 0600)
+}
+
+func (book addressBook) labels() []string {
+	labels := make([]string, 0, len(book))
+	for l := range book {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// labelFor returns the label of the given address, if any
+func (book addressBook) labelFor(address types.Address) string {
+	for _, l := range book.labels() {
+		if book[l] == address {
+			return l
+		}
+	}
+	return ""
+}
+
+func validateLabel(label string) error {
+	if len(label) == 0 {
+		return errors.New("label cannot be empty")
+	}
+	if strings.ContainsAny(label, " \t\n@/") {
+		return errors.New("label cannot contain whitespace, '@' or '/'")
+	}
+	return nil
+}
+
+// parseAddress parses a bech32 address or resolves an @label from the address book
+func parseAddress(s string) (types.Address, error) {
+	if !strings.HasPrefix(s, "@") {
+		return types.ParseAddress(s)
+	}
+	book, err := readAddressBook()
+	if err != nil {
+		return types.ZeroAddress, err
+	}
+	address, ok := book[strings.TrimPrefix(s, "@")]
+	if !ok {
+		return types.ZeroAddress, fmt.Errorf("label %s not found in the address book", s)
+	}
+	return address, nil
+}
+
+var znnCliAddressBookAdd = &cli.Command{
+	Name:  "addressbook.add",
+	Usage: "label address",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 2 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("addressbook.add label address")
+			return nil
+		}
+
+		label := strings.TrimPrefix(cCtx.Args().Get(0), "@")
+		if err := validateLabel(label); err != nil {
+			fmt.Println("Error bad label:", err)
+			return err
+		}
+		address, err := types.ParseAddress(cCtx.Args().Get(1))
+		if err != nil {
+			fmt.Println("Error bad address:", err)
+			return err
+		}
+
+		book, err := readAddressBook()
+		if err != nil {
+			fmt.Println("Error reading address book:", err)
+			return err
+		}
+		if existing, ok := book[label]; ok && existing != address {
+			fmt.Println("Error! Label", label, "is already used for", existing)
+			return nil
+		}
+		book[label] = address
+		if err := writeAddressBook(book); err != nil {
+			fmt.Println("Error writing address book:", err)
+			return err
+		}
+
+		fmt.Println("Added", address, "as @"+label)
+		return nil
+	},
+}
+
+var znnCliAddressBookList = &cli.Command{
+	Name:  "addressbook.list",
+	Usage: "",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 0 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("addressbook.list")
+			return nil
+		}
+
+		book, err := readAddressBook()
+		if err != nil {
+			fmt.Println("Error reading address book:", err)
+			return err
+		}
+		if len(book) == 0 {
+			fmt.Println("No addresses found")
+			return nil
+		}
+		fmt.Println("Address book:")
+		for _, l := range book.labels() {
+			fmt.Printf("  @%s %s\n", l, book[l])
+		}
+		return nil
+	},
+}
+
+var znnCliAddressBookRemove = &cli.Command{
+	Name:  "addressbook.remove",
+	Usage: "label",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("addressbook.remove label")
+			return nil
+		}
+
+		label := strings.TrimPrefix(cCtx.Args().Get(0), "@")
+		book, err := readAddressBook()
+		if err != nil {
+			fmt.Println("Error reading address book:", err)
+			return err
+		}
+		if _, ok := book[label]; !ok {
+			fmt.Println("Error! Label", label, "was not found")
+			return nil
+		}
+		delete(book, label)
+		if err := writeAddressBook(book); err != nil {
+			fmt.Println("Error writing address book:", err)
+			return err
+		}
+
+		fmt.Println("Removed @" + label)
+		return nil
+	},
+}
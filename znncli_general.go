@@ -3,13 +3,69 @@ package main
 import (
 	"fmt"
 	"math/big"
+	"sync"
 
 	"github.com/hypercore-one/go-zdk/utils"
 	"github.com/hypercore-one/go-zdk/utils/template"
+	signer "github.com/hypercore-one/go-zdk/wallet"
+	"github.com/hypercore-one/go-zdk/zdk"
 	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/chain/nom"
 	"github.com/zenon-network/go-zenon/common/types"
 )
 
+var signingBanner sync.Once
+
+// sendBlock signs and publishes tmpl after checking that the node is on the
+// chain of the block. The network is printed before the first block of a
+// command.
+func sendBlock(z *zdk.Zdk, tmpl *nom.AccountBlock, kp signer.Signer) (*nom.AccountBlock, error) {
+	frontier, err := z.Ledger.GetFrontierMomentum()
+	if err != nil {
+		return nil, err
+	}
+	if frontier.ChainIdentifier != tmpl.ChainIdentifier {
+		return nil, fmt.Errorf("refusing to sign for chain %d, the node is on chain %d, use --chainId %d or --chainId auto",
+			tmpl.ChainIdentifier, frontier.ChainIdentifier, frontier.ChainIdentifier)
+	}
+	signingBanner.Do(func() {
+		endpoint := url
+		if c, ok := z.Client.(*nodeClient); ok {
+			endpoint = c.url()
+		}
+		fmt.Printf("Signing as %v on chain %d via %s at momentum %d\n",
+			kp.Address(), frontier.ChainIdentifier, endpoint, frontier.Height)
+	})
+	return utils.Send(z, tmpl, kp, false)
+}
+
+// sendTokens sends amount in base units of zts to toAddress
+func sendTokens(z *zdk.Zdk, kp signer.Signer, toAddress types.Address, zts types.ZenonTokenStandard, amount *big.Int) (*nom.AccountBlock, error) {
+	tmpl := template.Send(z.ProtocolVersion(), z.ChainIdentifier(), toAddress, zts, amount, []byte{})
+	return sendBlock(z, tmpl, kp)
+}
+
+// receiveAll receives every unreceived block of kp and returns their number
+func receiveAll(z *zdk.Zdk, kp signer.Signer) (int, error) {
+	received := 0
+	for {
+		unreceived, err := z.Ledger.GetUnreceivedBlocksByAddress(kp.Address(), 0, 5)
+		if err != nil {
+			return received, err
+		}
+		if len(unreceived.List) == 0 {
+			return received, nil
+		}
+		for _, block := range unreceived.List {
+			temp := template.Receive(z.ProtocolVersion(), z.ChainIdentifier(), block.Hash)
+			if _, err := sendBlock(z, temp, kp); err != nil {
+				return received, err
+			}
+			received++
+		}
+	}
+}
+
 // TODO message data
 var znnCliSend = &cli.Command{
 	Name:  "send",
@@ -32,7 +88,7 @@ var znnCliSend = &cli.Command{
 			return err
 		}
 
-		toAddress, err := types.ParseAddress(cCtx.Args().Get(0))
+		toAddress, err := parseAddress(cCtx.Args().Get(0))
 		if err != nil {
 			fmt.Println("Error bad toAddress:", err)
 			return err
@@ -60,8 +116,7 @@ var znnCliSend = &cli.Command{
 
 		amount = amount.Mul(amount, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(token.Decimals)), nil))
 
-		tmpl := template.Send(z.ProtocolVersion(), z.ChainIdentifier(), toAddress, zts, amount, []byte{})
-		_, err = utils.Send(z, tmpl, kp, false)
+		_, err = sendTokens(z, kp, toAddress, zts, amount)
 		if err != nil {
 			fmt.Println("Error sending tx", err)
 			return err
@@ -81,9 +136,9 @@ var znnCliUnreceived = &cli.Command{
 			return nil
 		}
 
-		kp, err := getZnnCliSigner(walletDir, cCtx)
+		address, err := getZnnCliAddress(walletDir, cCtx)
 		if err != nil {
-			fmt.Println("Error getting signer:", err)
+			fmt.Println("Error getting address:", err)
 			return err
 		}
 		z, err := connect(url, chainId)
@@ -92,7 +147,7 @@ var znnCliUnreceived = &cli.Command{
 			return err
 		}
 
-		unreceived, err := z.Ledger.GetUnreceivedBlocksByAddress(kp.Address(), 0, 5)
+		unreceived, err := z.Ledger.GetUnreceivedBlocksByAddress(address, 0, 5)
 		if err != nil {
 			fmt.Println("Error fetching unreceived txs:", err)
 			return err
@@ -153,20 +208,9 @@ var znnCliReceiveAll = &cli.Command{
 		}
 		fmt.Println("Please wait ...")
 
-		for unreceived.Count > 0 {
-			for _, block := range unreceived.List {
-				temp := template.Receive(z.ProtocolVersion(), z.ChainIdentifier(), block.Hash)
-				_, err = utils.Send(z, temp, kp, false)
-				if err != nil {
-					fmt.Println("Error receiving txs:", err)
-					return err
-				}
-			}
-			unreceived, err = z.Ledger.GetUnreceivedBlocksByAddress(kp.Address(), 0, 5)
-			if err != nil {
-				fmt.Println("Error fetching unreceived txs:", err)
-				return err
-			}
+		if _, err := receiveAll(z, kp); err != nil {
+			fmt.Println("Error receiving txs:", err)
+			return err
 		}
 
 		fmt.Println("Done")
@@ -182,25 +226,22 @@ var znnCliBalance = &cli.Command{
 			fmt.Println("balance")
 			return nil
 		}
-		kp, err := getZnnCliSigner(walletDir, cCtx)
+		address, err := getZnnCliAddress(walletDir, cCtx)
 		if err != nil {
 			return err
 		}
-		if kp == nil {
-			return nil
-		}
 
 		z, err := connect(url, chainId)
 		if err != nil {
 			return err
 		}
-		info, err := z.Ledger.GetAccountInfoByAddress(kp.Address())
+		info, err := z.Ledger.GetAccountInfoByAddress(address)
 		if err != nil {
 			return err
 		}
-		fmt.Println("Balance for account-chain", kp.Address().String(), "having height", info.AccountHeight)
+		fmt.Println("Balance for account-chain", address.String(), "having height", info.AccountHeight)
 		if len(info.BalanceInfoMap) == 0 {
-			fmt.Println("  No coins or tokens at address", kp.Address().String())
+			fmt.Println("  No coins or tokens at address", address.String())
 		}
 		for zts, entry := range info.BalanceInfoMap {
 			fmt.Println(" ", formatAmount(entry.Balance, entry.TokenInfo.Decimals), entry.TokenInfo.TokenSymbol, entry.TokenInfo.TokenDomain, zts.String())
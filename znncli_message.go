@@ -0,0 +1,207 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/wallet"
+)
+
+// The signed payload is sha3-256(signedMessagePrefix || message). The prefix
+// makes sure a message signature can never be replayed as a block signature.
+const signedMessagePrefix = "Zenon Signed Message:\n"
+
+const signedMessageVersion = 1
+
+// signedMessage is the envelope written by message.sign and read by
+// message.verify. PublicKey and Signature are hex encoded, MessageHash is the
+// hex encoded sha3-256 hash of the raw message. Message is only set when the
+// message was provided as text.
+type signedMessage struct {
+	Version     int           `json:"version"`
+	Address     types.Address `json:"address"`
+	PublicKey   string        `json:"publicKey"`
+	Signature   string        `json:"signature"`
+	MessageHash string        `json:"messageHash"`
+	Message     string        `json:"message,omitempty"`
+}
+
+func signedMessagePayload(message []byte) []byte {
+	h := types.NewHash(append([]byte(signedMessagePrefix), message...))
+	return h.Bytes()
+}
+
+func readMessage(cCtx *cli.Context, argIndex int) ([]byte, bool, error) {
+	if cCtx.IsSet("file") {
+		data, err := os.ReadFile(cCtx.String("file"))
+		return data, false, err
+	}
+	if cCtx.NArg() > argIndex {
+		return []byte(cCtx.Args().Get(argIndex)), true, nil
+	}
+	return nil, false, nil
+}
+
+// verify checks the envelope against the message and returns the address
+// derived from the public key
+func (m *signedMessage) verify(message []byte) (types.Address, error) {
+	if m.Version != signedMessageVersion {
+		return types.ZeroAddress, fmt.Errorf("unsupported envelope version %d", m.Version)
+	}
+	publicKey, err := hex.DecodeString(m.PublicKey)
+	if err != nil {
+		return types.ZeroAddress, err
+	}
+	signature, err := hex.DecodeString(m.Signature)
+	if err != nil {
+		return types.ZeroAddress, err
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return types.ZeroAddress, errors.New("invalid public key length")
+	}
+	if hex.EncodeToString(types.NewHash(message).Bytes()) != m.MessageHash {
+		return types.ZeroAddress, errors.New("message does not match the envelope message hash")
+	}
+
+	ok, err := wallet.VerifySignature(publicKey, signedMessagePayload(message), signature)
+	if err != nil {
+		return types.ZeroAddress, err
+	}
+	if !ok {
+		return types.ZeroAddress, errors.New("invalid signature")
+	}
+
+	address := types.PubKeyToAddress(publicKey)
+	if address != m.Address {
+		return types.ZeroAddress, fmt.Errorf("public key belongs to %v, not %v", address, m.Address)
+	}
+	return address, nil
+}
+
+var znnCliMessageSign = &cli.Command{
+	Name:  "message.sign",
+	Usage: "[text]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "file",
+			Usage: "Sign the contents of this file instead of text",
+		},
+		&cli.StringFlag{
+			Name:  "out",
+			Usage: "Write the signed envelope to this file instead of printing it",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if !(cCtx.NArg() == 1 && !cCtx.IsSet("file") || cCtx.NArg() == 0 && cCtx.IsSet("file")) {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("message.sign text")
+			fmt.Println("message.sign --file path")
+			return nil
+		}
+
+		message, isText, err := readMessage(cCtx, 0)
+		if err != nil {
+			fmt.Println("Error reading message:", err)
+			return err
+		}
+
+		kp, err := getZnnCliSigner(walletDir, cCtx)
+		if err != nil {
+			fmt.Println("Error getting signer:", err)
+			return err
+		}
+
+		envelope := signedMessage{
+			Version:     signedMessageVersion,
+			Address:     kp.Address(),
+			PublicKey:   hex.EncodeToString(kp.PublicKey()),
+			Signature:   hex.EncodeToString(kp.Sign(signedMessagePayload(message))),
+			MessageHash: hex.EncodeToString(types.NewHash(message).Bytes()),
+		}
+		if isText {
+			envelope.Message = string(message)
+		}
+
+		data, err := json.MarshalIndent(envelope, "", "    ")
+		if err != nil {
+			return err
+		}
+		if cCtx.IsSet("out") {
+			if err := os.WriteFile(cCtx.String("out"), data, 0644); err != nil {
+				fmt.Println("Error writing envelope:", err)
+				return err
+			}
+			fmt.Println("Signed message written to", cCtx.String("out"))
+			return nil
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+var znnCliMessageVerify = &cli.Command{
+	Name:  "message.verify",
+	Usage: "envelopeFile [text]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "file",
+			Usage: "Verify the signature against the contents of this file",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if !(cCtx.NArg() == 1 || cCtx.NArg() == 2 && !cCtx.IsSet("file")) {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("message.verify envelopeFile [text]")
+			fmt.Println("message.verify --file path envelopeFile")
+			return nil
+		}
+
+		data, err := os.ReadFile(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error reading envelope:", err)
+			return err
+		}
+		envelope := new(signedMessage)
+		if err := json.Unmarshal(data, envelope); err != nil {
+			fmt.Println("Error parsing envelope:", err)
+			return err
+		}
+
+		message, _, err := readMessage(cCtx, 1)
+		if err != nil {
+			fmt.Println("Error reading message:", err)
+			return err
+		}
+		if message == nil {
+			message = []byte(envelope.Message)
+		}
+
+		address, err := envelope.verify(message)
+		if err != nil {
+			fmt.Println("Error! Signature is not valid:", err)
+			return err
+		}
+
+		// An --address (or @label) pins the expected signer
+		if cCtx.IsSet("address") {
+			expected, err := parseAddress(cCtx.String("address"))
+			if err != nil {
+				fmt.Println("Error bad address:", err)
+				return err
+			}
+			if expected != address {
+				fmt.Println("Error! Message was signed by", address, "and not by", expected)
+				return errors.New("signer mismatch")
+			}
+		}
+
+		fmt.Println("Valid signature by", address)
+		return nil
+	},
+}
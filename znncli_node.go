@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/protocol"
+)
+
+// exit code of node.health for a reachable but unhealthy node
+const nodeUnhealthyExitCode = 2
+
+var syncStateNames = map[protocol.SyncState]string{
+	protocol.Unknown:        "unknown",
+	protocol.Syncing:        "syncing",
+	protocol.SyncDone:       "synced",
+	protocol.NotEnoughPeers: "not enough peers",
+}
+
+var znnCliNodeInfo = &cli.Command{
+	Name: "node.info",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 0 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("node.info")
+			return nil
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+
+		process, err := z.Stats.ProcessInfo()
+		if err != nil {
+			fmt.Println("Error fetching process info:", err)
+			return err
+		}
+		osInfo, err := z.Stats.OsInfo()
+		if err != nil {
+			fmt.Println("Error fetching os info:", err)
+			return err
+		}
+		m, err := z.Ledger.GetFrontierMomentum()
+		if err != nil {
+			fmt.Println("Error fetching frontier momentum:", err)
+			return err
+		}
+
+		fmt.Println("Node version:", process.Version, process.Commit)
+		fmt.Println("Chain identifier:", m.ChainIdentifier)
+		// the stats rpc has no protocol version, the momentum version is the
+		// closest the node reports
+		fmt.Println("Momentum version:", m.Version)
+		fmt.Println("Momentum height:", m.Height)
+		fmt.Printf("OS: %s %s %s (%s), kernel %s\n", osInfo.Os, osInfo.Platform, osInfo.PlatformVersion, osInfo.PlatformFamily, osInfo.KernelVersion)
+		fmt.Printf("CPUs: %d, goroutines: %d\n", osInfo.NumCPU, osInfo.NumGoroutine)
+		fmt.Printf("Memory: %d MiB free of %d MiB\n", osInfo.MemoryFree/1024/1024, osInfo.MemoryTotal/1024/1024)
+		return nil
+	},
+}
+
+var znnCliNodeSync = &cli.Command{
+	Name: "node.sync",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 0 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("node.sync")
+			return nil
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+		info, err := z.Stats.SyncInfo()
+		if err != nil {
+			fmt.Println("Error fetching sync info:", err)
+			return err
+		}
+		fmt.Println("Sync state:", syncStateNames[info.State])
+		fmt.Printf("Height: %d / %d\n", info.CurrentHeight, info.TargetHeight)
+		return nil
+	},
+}
+
+var znnCliNodePeers = &cli.Command{
+	Name: "node.peers",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 0 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("node.peers")
+			return nil
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+		info, err := z.Stats.NetworkInfo()
+		if err != nil {
+			fmt.Println("Error fetching network info:", err)
+			return err
+		}
+		if info.Self != nil {
+			fmt.Println("Self:", info.Self.Name, info.Self.IP, info.Self.PublicKey)
+		}
+		fmt.Println(info.NumPeers, "peer(s)")
+		if len(info.Peers) == 0 {
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Name\tIP\tPublic key")
+		for _, peer := range info.Peers {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", peer.Name, peer.IP, peer.PublicKey)
+		}
+		return w.Flush()
+	},
+}
+
+var znnCliNodeHealth = &cli.Command{
+	Name:  "node.health",
+	Usage: "exits with 2 if the node is unhealthy and 1 if it is unreachable",
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "max-age",
+			Usage: "Maximum age of the frontier momentum",
+			Value: time.Minute,
+		},
+		&cli.IntFlag{
+			Name:  "min-peers",
+			Usage: "Minimum number of peers",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 0 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("node.health [--max-age 1m] [--min-peers n]")
+			return nil
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+		sync, err := z.Stats.SyncInfo()
+		if err != nil {
+			fmt.Println("Error fetching sync info:", err)
+			return err
+		}
+		m, err := z.Ledger.GetFrontierMomentum()
+		if err != nil {
+			fmt.Println("Error fetching frontier momentum:", err)
+			return err
+		}
+		network, err := z.Stats.NetworkInfo()
+		if err != nil {
+			fmt.Println("Error fetching network info:", err)
+			return err
+		}
+
+		var problems []string
+		if sync.State != protocol.SyncDone {
+			problems = append(problems, fmt.Sprintf("sync state is %s at %d / %d", syncStateNames[sync.State], sync.CurrentHeight, sync.TargetHeight))
+		}
+		age := time.Since(time.Unix(int64(m.TimestampUnix), 0)).Round(time.Second)
+		if maxAge := cCtx.Duration("max-age"); age > maxAge {
+			problems = append(problems, fmt.Sprintf("frontier momentum %d is %v old, more than %v", m.Height, age, maxAge))
+		}
+		if minPeers := cCtx.Int("min-peers"); network.NumPeers < minPeers {
+			problems = append(problems, fmt.Sprintf("%d peer(s), less than %d", network.NumPeers, minPeers))
+		}
+
+		if len(problems) > 0 {
+			return cli.Exit("Unhealthy: "+strings.Join(problems, "; "), nodeUnhealthyExitCode)
+		}
+		fmt.Printf("Healthy: synced at momentum %d from %v ago with %d peer(s)\n", m.Height, age, network.NumPeers)
+		return nil
+	},
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 
-	"github.com/hypercore-one/go-zdk/utils"
 	"github.com/urfave/cli/v2"
 )
 
@@ -47,9 +46,9 @@ var znnCliPillarUncollected = &cli.Command{
 			return nil
 		}
 
-		kp, err := getZnnCliSigner(walletDir, cCtx)
+		address, err := getZnnCliAddress(walletDir, cCtx)
 		if err != nil {
-			fmt.Println("Error getting signer:", err)
+			fmt.Println("Error getting address:", err)
 			return err
 		}
 		z, err := connect(url, chainId)
@@ -57,7 +56,7 @@ var znnCliPillarUncollected = &cli.Command{
 			fmt.Println("Error connecting to Zenon Network:", err)
 			return err
 		}
-		uncollected, err := z.Embedded.Pillar.GetUncollectedReward(kp.Address())
+		uncollected, err := z.Embedded.Pillar.GetUncollectedReward(address)
 		if err != nil {
 			fmt.Println("Error getting uncollected pillar reward(s):", err)
 			return err
@@ -101,7 +100,7 @@ var znnCliPillarCollect = &cli.Command{
 			fmt.Println("Error templating pillar collect tx:", err)
 			return err
 		}
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending pillar collect tx:", err)
 			return err
@@ -142,7 +141,7 @@ var znnCliPillarDelegate = &cli.Command{
 			return err
 		}
 		fmt.Println("Delegating to Pillar", pillar)
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending pillar delegate tx:", err)
 			return err
@@ -179,7 +178,7 @@ var znnCliPillarUndelegate = &cli.Command{
 			return err
 		}
 		fmt.Println("Undelegating ...")
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending pillar undelegate tx:", err)
 			return err
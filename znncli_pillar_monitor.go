@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/hypercore-one/go-zdk/zdk"
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/rpc/api/subscribe"
+)
+
+const (
+	pillarMonitorDir            = "pillar-monitor"
+	pillarMonitorRetryInterval  = 10 * time.Second
+	pillarMonitorWebhookTimeout = 10 * time.Second
+
+	// pillarEpochDuration is the consensus.EpochDuration of the node, the
+	// pillar stats are reset when a new epoch starts
+	pillarEpochDuration = 24 * time.Hour
+)
+
+// pillarMonitorConfig holds the alert hooks of pillar.monitor. Every hook is
+// optional, alerts are always printed.
+type pillarMonitorConfig struct {
+	Exec            string  `json:"exec"`
+	Webhook         string  `json:"webhook"`
+	Log             string  `json:"log"`
+	MinWeightChange float64 `json:"minWeightChange"`
+}
+
+// pillarMonitorState is what has been alerted so far. It is kept in
+// ~/.nomctl/pillar-monitor/<name>.json so a restart doesn't alert again.
+type pillarMonitorState struct {
+	Height   uint64 `json:"height"`
+	Epoch    uint64 `json:"epoch"`
+	Produced uint64 `json:"produced"`
+	Expected uint64 `json:"expected"`
+	Missed   uint64 `json:"missed"`
+	Rank     int    `json:"rank"`
+	Weight   string `json:"weight"`
+	Missing  bool   `json:"missing"`
+}
+
+type pillarAlert struct {
+	Pillar  string `json:"pillar"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	Height  uint64 `json:"height"`
+	Time    int64  `json:"time"`
+}
+
+type pillarMonitor struct {
+	name      string
+	config    pillarMonitorConfig
+	statePath string
+	state     *pillarMonitorState
+	// genesis is the timestamp of the genesis momentum
+	genesis uint64
+}
+
+func pillarMonitorStatePath(name string) string {
+	return filepath.Join(nomctlDir, pillarMonitorDir, name+".json")
+}
+
+// readPillarMonitorState returns nil if the pillar hasn't been monitored yet
+func readPillarMonitorState(path string) (*pillarMonitorState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := new(pillarMonitorState)
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// weightChanged reports whether weight differs from the last alerted weight
+// by at least minChange percent
+func weightChanged(last string, weight *big.Int, minChange float64) bool {
+	previous, ok := new(big.Int).SetString(last, 10)
+	if !ok || previous.Sign() == 0 {
+		return weight.Sign() != 0
+	}
+	diff := new(big.Float).SetInt(new(big.Int).Abs(new(big.Int).Sub(weight, previous)))
+	percent, _ := diff.Quo(diff, new(big.Float).SetInt(previous)).Float64()
+	return percent*100 >= minChange && weight.Cmp(previous) != 0
+}
+
+// pillarEpoch returns the epoch of a momentum timestamp
+func pillarEpoch(genesis, timestamp uint64) uint64 {
+	if timestamp < genesis {
+		return 0
+	}
+	return (timestamp - genesis) / uint64(pillarEpochDuration/time.Second)
+}
+
+// check compares the pillar at the frontier momentum with the state and
+// returns the alerts for what changed
+func (m *pillarMonitor) check(z *zdk.Zdk) ([]pillarAlert, error) {
+	if m.genesis == 0 {
+		momentums, err := z.Ledger.GetMomentumsByHeight(1, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(momentums.List) == 0 {
+			return nil, errors.New("no genesis momentum")
+		}
+		m.genesis = momentums.List[0].TimestampUnix
+	}
+	frontier, err := z.Ledger.GetFrontierMomentum()
+	if err != nil {
+		return nil, err
+	}
+	height, epoch := frontier.Height, pillarEpoch(m.genesis, frontier.TimestampUnix)
+	p, err := z.Embedded.Pillar.GetByName(m.name)
+	if err != nil {
+		return nil, err
+	}
+	var alerts []pillarAlert
+	alert := func(kind, format string, a ...interface{}) {
+		alerts = append(alerts, pillarAlert{
+			Pillar:  m.name,
+			Kind:    kind,
+			Message: fmt.Sprintf(format, a...),
+			Height:  height,
+			Time:    time.Now().Unix(),
+		})
+	}
+
+	first := m.state == nil
+	if first {
+		m.state = &pillarMonitorState{Weight: "0"}
+	}
+	state := m.state
+	state.Height = height
+	if epoch != state.Epoch {
+		if !first {
+			fmt.Printf("Epoch %d ended with %d / %d momentums\n", state.Epoch, state.Produced, state.Expected)
+		}
+		state.Epoch, state.Produced, state.Expected, state.Missed = epoch, 0, 0, 0
+	}
+
+	if p == nil || p.Name == "" {
+		if !state.Missing {
+			alert("missing", "Pillar %s is not registered", m.name)
+			state.Missing = true
+		}
+		return alerts, nil
+	}
+	if state.Missing {
+		alert("registered", "Pillar %s is registered", m.name)
+		state.Missing = false
+	}
+
+	var produced, expected uint64
+	if p.CurrentStats != nil {
+		produced, expected = p.CurrentStats.ProducedMomentums, p.CurrentStats.ExpectedMomentums
+	}
+	state.Produced, state.Expected = produced, expected
+	if missed := expected - min(produced, expected); missed > state.Missed {
+		alert("missed", "Pillar %s missed %d momentum(s), %d / %d in the current epoch", m.name, missed-state.Missed, produced, expected)
+		state.Missed = missed
+	}
+
+	if !first && p.Rank != state.Rank {
+		alert("rank", "Pillar %s moved from rank #%d to #%d", m.name, state.Rank+1, p.Rank+1)
+	}
+	state.Rank = p.Rank
+
+	if first {
+		state.Weight = p.Weight.String()
+	} else if weightChanged(state.Weight, p.Weight, m.config.MinWeightChange) {
+		previous, _ := new(big.Int).SetString(state.Weight, 10)
+		if previous == nil {
+			previous = big.NewInt(0)
+		}
+		alert("weight", "Pillar %s weight changed from %s to %s ZNN", m.name, formatAmount(previous, ZnnDecimals), formatAmount(p.Weight, ZnnDecimals))
+		state.Weight = p.Weight.String()
+	}
+	return alerts, nil
+}
+
+func (m *pillarMonitor) saveState() error {
+	if err := os.MkdirAll(filepath.Dir(m.statePath), 0700); err != nil {
+		return err
+	}
+	return writeJSONFile(m.statePath, m.state, 0600)
+}
+
+// fire prints an alert and runs the configured hooks. A failing hook is
+// printed and doesn't stop the others.
+func (m *pillarMonitor) fire(a pillarAlert) {
+	fmt.Printf("%s [%s] %s at momentum %d\n", time.Unix(a.Time, 0).Format(time.RFC3339), a.Kind, a.Message, a.Height)
+
+	if m.config.Log != "" {
+		if err := appendAlertLog(m.config.Log, a); err != nil {
+			fmt.Println("Error writing alert log:", err)
+		}
+	}
+	if m.config.Webhook != "" {
+		if err := postAlert(m.config.Webhook, a); err != nil {
+			fmt.Println("Error posting alert:", err)
+		}
+	}
+	if m.config.Exec != "" {
+		cmd := exec.Command("sh", "-c", m.config.Exec)
+		cmd.Env = append(os.Environ(),
+			"NOMCTL_ALERT_PILLAR="+a.Pillar,
+			"NOMCTL_ALERT_KIND="+a.Kind,
+			"NOMCTL_ALERT_MESSAGE="+a.Message,
+			fmt.Sprintf("NOMCTL_ALERT_HEIGHT=%d", a.Height),
+		)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Println("Error running alert command:", err)
+		}
+	}
+}
+
+func appendAlertLog(path string, a pillarAlert) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "%s [%s] %s at momentum %d\n", time.Unix(a.Time, 0).Format(time.RFC3339), a.Kind, a.Message, a.Height)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func postAlert(webhook string, a pillarAlert) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: pillarMonitorWebhookTimeout}
+	resp, err := client.Post(webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (m *pillarMonitor) update(z *zdk.Zdk) error {
+	alerts, err := m.check(z)
+	if err != nil {
+		return err
+	}
+	for _, a := range alerts {
+		m.fire(a)
+	}
+	return m.saveState()
+}
+
+// watch checks the pillar at every new momentum until the subscription fails
+func (m *pillarMonitor) watch(z *zdk.Zdk) error {
+	if err := m.update(z); err != nil {
+		return err
+	}
+	if m.state.Missing {
+		fmt.Printf("Monitoring pillar %s at momentum %d, it is not registered\n", m.name, m.state.Height)
+	} else {
+		fmt.Printf("Monitoring pillar %s at momentum %d: %d / %d momentums in epoch %d, rank #%d\n", m.name, m.state.Height, m.state.Produced, m.state.Expected, m.state.Epoch, m.state.Rank+1)
+	}
+
+	ch := make(chan []subscribe.Momentum)
+	sub, err := z.Subscribe.ToMomentums(ch)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return err
+		case momentums := <-ch:
+			if len(momentums) == 0 {
+				continue
+			}
+			if err := m.update(z); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+var znnCliPillarMonitor = &cli.Command{
+	Name:  "pillar.monitor",
+	Usage: "name",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "exec",
+			Usage: "Command run by sh for every alert, NOMCTL_ALERT_PILLAR, _KIND, _MESSAGE and _HEIGHT are set",
+		},
+		&cli.StringFlag{
+			Name:  "webhook",
+			Usage: "URL the alerts are POSTed to as JSON",
+		},
+		&cli.StringFlag{
+			Name:  "log",
+			Usage: "File the alerts are appended to",
+		},
+		&cli.Float64Flag{
+			Name:  "min-weight-change",
+			Usage: "Minimum weight change in percent to alert",
+			Value: 1,
+		},
+		&cli.StringFlag{
+			Name:  "state",
+			Usage: "State file, defaults to ~/.nomctl/pillar-monitor/<name>.json",
+		},
+		&cli.StringFlag{
+			Name:  "config",
+			Usage: "Config file with the pillarMonitor hooks, defaults to ~/.nomctl/config.json",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("pillar.monitor [--exec cmd] [--webhook url] [--log file] name")
+			return nil
+		}
+		name := cCtx.Args().Get(0)
+
+		path := cCtx.String("config")
+		if path == "" {
+			path = configPath()
+		}
+		config, err := readConfig(path)
+		if err != nil {
+			fmt.Println("Error reading config:", err)
+			return err
+		}
+		m := &pillarMonitor{
+			name:      name,
+			config:    config.PillarMonitor,
+			statePath: cCtx.String("state"),
+		}
+		if cCtx.IsSet("exec") {
+			m.config.Exec = cCtx.String("exec")
+		}
+		if cCtx.IsSet("webhook") {
+			m.config.Webhook = cCtx.String("webhook")
+		}
+		if cCtx.IsSet("log") {
+			m.config.Log = cCtx.String("log")
+		}
+		if cCtx.IsSet("min-weight-change") || m.config.MinWeightChange == 0 {
+			m.config.MinWeightChange = cCtx.Float64("min-weight-change")
+		}
+		if m.statePath == "" {
+			m.statePath = pillarMonitorStatePath(name)
+		}
+		if m.state, err = readPillarMonitorState(m.statePath); err != nil {
+			fmt.Println("Error reading state:", err)
+			return err
+		}
+
+		// z is kept, the client drops a failed connection and dials the next
+		// healthy endpoint when watch is retried
+		var z *zdk.Zdk
+		for {
+			if z == nil {
+				z, err = connect(url, chainId)
+			}
+			if err == nil {
+				err = m.watch(z)
+			}
+			fmt.Println("Error monitoring pillar:", err)
+			fmt.Println("Reconnecting in", pillarMonitorRetryInterval)
+			time.Sleep(pillarMonitorRetryInterval)
+		}
+	},
+}
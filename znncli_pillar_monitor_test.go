@@ -0,0 +1,53 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestWeightChanged(t *testing.T) {
+	tests := []struct {
+		last      string
+		weight    int64
+		minChange float64
+		want      bool
+	}{
+		{"0", 0, 1, false},
+		{"0", 1, 1, true},
+		{"", 0, 1, false},
+		{"invalid", 5, 1, true},
+		{"1000", 1000, 0, false},
+		{"1000", 1009, 1, false},
+		{"1000", 1010, 1, true},
+		{"1000", 990, 1, true},
+		{"1000", 991, 1, false},
+		{"1000", 1001, 0, true},
+		{"1000", 0, 50, true},
+		{"1000", 1400, 50, false},
+	}
+	for _, tt := range tests {
+		if got := weightChanged(tt.last, big.NewInt(tt.weight), tt.minChange); got != tt.want {
+			t.Errorf("weightChanged(%q, %d, %v) = %v, want %v", tt.last, tt.weight, tt.minChange, got, tt.want)
+		}
+	}
+}
+
+func TestPillarEpoch(t *testing.T) {
+	const genesis = 1700000000
+	day := uint64(pillarEpochDuration.Seconds())
+	tests := []struct {
+		timestamp uint64
+		want      uint64
+	}{
+		{genesis - 10, 0},
+		{genesis, 0},
+		{genesis + day - 1, 0},
+		{genesis + day, 1},
+		{genesis + 10*day + 5, 10},
+	}
+	for _, tt := range tests {
+		if got := pillarEpoch(genesis, tt.timestamp); got != tt.want {
+			t.Errorf("pillarEpoch(%d, %d) = %d, want %d", uint64(genesis), tt.timestamp, got, tt.want)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"math/big"
 
-	"github.com/hypercore-one/go-zdk/utils"
 	"github.com/urfave/cli/v2"
 	"github.com/zenon-network/go-zenon/common/types"
 	"github.com/zenon-network/go-zenon/vm/constants"
@@ -20,9 +19,9 @@ var znnCliPlasmaList = &cli.Command{
 			return nil
 		}
 
-		kp, err := getZnnCliSigner(walletDir, cCtx)
+		address, err := getZnnCliAddress(walletDir, cCtx)
 		if err != nil {
-			fmt.Println("Error getting signer:", err)
+			fmt.Println("Error getting address:", err)
 			return err
 		}
 		z, err := connect(url, chainId)
@@ -34,7 +33,7 @@ var znnCliPlasmaList = &cli.Command{
 		pageIndex := 0
 		pageSize := 25
 
-		fusions, err := z.Embedded.Plasma.GetEntriesByAddress(kp.Address(), uint32(pageIndex), uint32(pageSize))
+		fusions, err := z.Embedded.Plasma.GetEntriesByAddress(address, uint32(pageIndex), uint32(pageSize))
 		if err != nil {
 			fmt.Println("Error getting plasma list:", err)
 			return err
@@ -64,9 +63,15 @@ var znnCliPlasmaGet = &cli.Command{
 			return nil
 		}
 
-		kp, err := getZnnCliSigner(walletDir, cCtx)
+		var address types.Address
+		var err error
+		if cCtx.NArg() == 1 {
+			address, err = parseAddress(cCtx.Args().Get(0))
+		} else {
+			address, err = getZnnCliAddress(walletDir, cCtx)
+		}
 		if err != nil {
-			fmt.Println("Error getting signer:", err)
+			fmt.Println("Error getting address:", err)
 			return err
 		}
 		z, err := connect(url, chainId)
@@ -75,11 +80,6 @@ var znnCliPlasmaGet = &cli.Command{
 			return err
 		}
 
-		address := kp.Address()
-		if cCtx.NArg() == 1 {
-			address = types.ParseAddressPanic(cCtx.Args().Get(0))
-		}
-
 		plasmaInfo, err := z.Embedded.Plasma.Get(address)
 		if err != nil {
 			fmt.Println("Error getting plasma info:", err)
@@ -115,7 +115,11 @@ var znnCliPlasmaFuse = &cli.Command{
 			return err
 		}
 
-		toAddress := types.ParseAddressPanic(cCtx.Args().Get(0))
+		toAddress, err := parseAddress(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error bad toAddress:", err)
+			return err
+		}
 		amount := big.NewInt(0)
 		amount, ok := amount.SetString(cCtx.Args().Get(1), 10)
 		if !ok {
@@ -142,7 +146,7 @@ var znnCliPlasmaFuse = &cli.Command{
 			fmt.Println("Error creating fusing plasma template:", err)
 			return err
 		}
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(z, template, kp)
 		if err != nil {
 			fmt.Println("Error fusing plasma:", err)
 			return err
@@ -232,7 +236,7 @@ var znnCliPlasmaCancel = &cli.Command{
 			fmt.Println("Error templating plasma cancel tx:", err)
 			return err
 		}
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending plasma cancel tx:", err)
 			return err
@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 
-	"github.com/hypercore-one/go-zdk/utils"
 	"github.com/urfave/cli/v2"
 )
 
@@ -17,9 +16,9 @@ var znnCliSentinelUncollected = &cli.Command{
 			return nil
 		}
 
-		kp, err := getZnnCliSigner(walletDir, cCtx)
+		address, err := getZnnCliAddress(walletDir, cCtx)
 		if err != nil {
-			fmt.Println("Error getting signer:", err)
+			fmt.Println("Error getting address:", err)
 			return err
 		}
 		z, err := connect(url, chainId)
@@ -27,7 +26,7 @@ var znnCliSentinelUncollected = &cli.Command{
 			fmt.Println("Error connecting to Zenon Network:", err)
 			return err
 		}
-		uncollected, err := z.Embedded.Sentinel.GetUncollectedReward(kp.Address())
+		uncollected, err := z.Embedded.Sentinel.GetUncollectedReward(address)
 		if err != nil {
 			fmt.Println("Error getting uncollected sentinel reward(s):", err)
 			return err
@@ -71,7 +70,7 @@ var znnCliSentinelCollect = &cli.Command{
 			fmt.Println("Error templating sentinel collect tx:", err)
 			return err
 		}
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending sentinel collect tx:", err)
 			return err
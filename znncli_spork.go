@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 
-	"github.com/hypercore-one/go-zdk/utils"
 	"github.com/urfave/cli/v2"
 	"github.com/zenon-network/go-zenon/common/types"
 	"github.com/zenon-network/go-zenon/vm/constants"
@@ -86,7 +85,7 @@ var znnCliSporkCreate = &cli.Command{
 			return err
 		}
 		fmt.Println("Creating spork...")
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending spork create tx:", err)
 			return err
@@ -127,7 +126,7 @@ var znnCliSporkActivate = &cli.Command{
 			return err
 		}
 		fmt.Println("Activating spork...")
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending spork activate tx:", err)
 			return err
@@ -6,7 +6,6 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/hypercore-one/go-zdk/utils"
 	"github.com/urfave/cli/v2"
 	"github.com/zenon-network/go-zenon/common/types"
 	"github.com/zenon-network/go-zenon/vm/constants"
@@ -22,9 +21,9 @@ var znnCliStakeList = &cli.Command{
 			return nil
 		}
 
-		kp, err := getZnnCliSigner(walletDir, cCtx)
+		address, err := getZnnCliAddress(walletDir, cCtx)
 		if err != nil {
-			fmt.Println("Error getting signer:", err)
+			fmt.Println("Error getting address:", err)
 			return err
 		}
 		z, err := connect(url, chainId)
@@ -54,7 +53,7 @@ var znnCliStakeList = &cli.Command{
 		}
 
 		currentTime := time.Now().Unix()
-		stakeList, err := z.Embedded.Stake.GetEntriesByAddress(kp.Address(), uint32(pageIndex), uint32(pageSize))
+		stakeList, err := z.Embedded.Stake.GetEntriesByAddress(address, uint32(pageIndex), uint32(pageSize))
 		if err != nil {
 			fmt.Println("Error getting stake list:", err)
 			return err
@@ -139,7 +138,7 @@ var znnCliStakeRegister = &cli.Command{
 			return err
 		}
 		fmt.Printf("Staking %v ZNN for %v month(s)\n", formatAmount(amount, ZnnDecimals), duration)
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending stake register tx:", err)
 			return err
@@ -230,7 +229,7 @@ var znnCliStakeRevoke = &cli.Command{
 			fmt.Println("Error templating stake cancel tx:", err)
 			return err
 		}
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending stake cancel tx:", err)
 			return err
@@ -250,9 +249,9 @@ var znnCliStakeUncollected = &cli.Command{
 			return nil
 		}
 
-		kp, err := getZnnCliSigner(walletDir, cCtx)
+		address, err := getZnnCliAddress(walletDir, cCtx)
 		if err != nil {
-			fmt.Println("Error getting signer:", err)
+			fmt.Println("Error getting address:", err)
 			return err
 		}
 		z, err := connect(url, chainId)
@@ -260,7 +259,7 @@ var znnCliStakeUncollected = &cli.Command{
 			fmt.Println("Error connecting to Zenon Network:", err)
 			return err
 		}
-		uncollected, err := z.Embedded.Stake.GetUncollectedReward(kp.Address())
+		uncollected, err := z.Embedded.Stake.GetUncollectedReward(address)
 		if err != nil {
 			fmt.Println("Error getting uncollected stake reward(s):", err)
 			return err
@@ -304,7 +303,7 @@ var znnCliStakeCollect = &cli.Command{
 			fmt.Println("Error templating stake collect tx:", err)
 			return err
 		}
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending stake collect tx:", err)
 			return err
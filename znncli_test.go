@@ -0,0 +1,22 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestGetZnnCliSignerRejectsAddress(t *testing.T) {
+	set := flag.NewFlagSet("znn-cli", flag.ContinueOnError)
+	set.String("address", "", "")
+	if err := set.Parse([]string{"--address", "z1qzsz8w2u8tafzdfckvn0vylxhjcquz6mh8s3en"}); err != nil {
+		t.Fatal(err)
+	}
+	// the wallet directory is not read, --address is rejected first
+	_, err := getZnnCliSigner(t.TempDir(), cli.NewContext(cli.NewApp(), set, nil))
+	if err == nil || !strings.Contains(err.Error(), "watch-only") {
+		t.Errorf("getZnnCliSigner with --address = %v, want the watch-only error", err)
+	}
+}
@@ -2,15 +2,84 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/tyler-smith/go-bip39"
 	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
 	"github.com/zenon-network/go-zenon/wallet"
 )
 
+// newKeyStoreFromEntropy builds a keyStore and sets its base address
+func newKeyStoreFromEntropy(entropy []byte) (*wallet.KeyStore, error) {
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return nil, err
+	}
+	ks := &wallet.KeyStore{
+		Entropy:  entropy,
+		Seed:     bip39.NewSeed(mnemonic, ""),
+		Mnemonic: mnemonic,
+	}
+	_, kp, err := ks.DeriveForIndexPath(0)
+	if err != nil {
+		return nil, err
+	}
+	ks.BaseAddress = kp.Address
+	return ks, nil
+}
+
+// encryptKeyStore encrypts the keyStore for path and returns the key file json
+func encryptKeyStore(ks *wallet.KeyStore, password string, path string) ([]byte, error) {
+	kf, err := ks.Encrypt(password)
+	if err != nil {
+		return nil, err
+	}
+	kf.Path = path
+	// kf.Write() uses 0700 as file mode
+	return json.MarshalIndent(kf, "", "    ")
+}
+
+// writeKeyStore encrypts the keyStore and writes it to dir/name, replacing an
+// existing keyStore of that name
+func writeKeyStore(ks *wallet.KeyStore, password string, dir string, name string) (string, error) {
+	path := filepath.Join(dir, name)
+	data, err := encryptKeyStore(ks, password, path)
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0600)
+}
+
+// createKeyStore is writeKeyStore for generated and recovered keyStores, it
+// fails instead of replacing an existing keyStore
+func createKeyStore(ks *wallet.KeyStore, password string, dir string, name string) (string, error) {
+	path := filepath.Join(dir, name)
+	data, err := encryptKeyStore(ks, password, path)
+	if err != nil {
+		return "", err
+	}
+	return path, writeFileExclusive(path, data, 0600)
+}
+
+// writeFileExclusive fails instead of overwriting an existing file
+func writeFileExclusive(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
 var znnCliWalletCreateNew = &cli.Command{
 	Name:  "wallet.createNew",
 	Usage: "passphrase [keyStoreName]",
@@ -23,14 +92,10 @@ var znnCliWalletCreateNew = &cli.Command{
 
 		// TODO finally implement a local keystore manager in go-zdk?
 		entropy, _ := bip39.NewEntropy(256)
-		mnemonic, _ := bip39.NewMnemonic(entropy)
-		ks := &wallet.KeyStore{
-			Entropy:  entropy,
-			Seed:     bip39.NewSeed(mnemonic, ""),
-			Mnemonic: mnemonic,
+		ks, err := newKeyStoreFromEntropy(entropy)
+		if err != nil {
+			return err
 		}
-		_, kp, _ := ks.DeriveForIndexPath(0)
-		ks.BaseAddress = kp.Address
 
 		name := ks.BaseAddress.String()
 		if cCtx.NArg() == 2 {
@@ -38,15 +103,10 @@ var znnCliWalletCreateNew = &cli.Command{
 		}
 
 		password := cCtx.Args().Get(0)
-		kf, _ := ks.Encrypt(password)
-		kf.Path = filepath.Join(walletDir, name)
-		//kf.Write()
-		// Uncomment when file mode is fixed
-		keyFileJson, err := json.MarshalIndent(kf, "", "    ")
-		if err != nil {
+		if _, err := writeKeyStore(ks, password, walletDir, name); err != nil {
+			fmt.Println("Error writing keyStore:", err)
 			return err
 		}
-		os.WriteFile(kf.Path, keyFileJson, 0600)
 
 		fmt.Println("keyStore successfully created:", name)
 		return nil
@@ -67,14 +127,10 @@ var znnCliWalletCreateFromMnemonic = &cli.Command{
 		ms := cCtx.Args().Get(0)
 		// TODO add in validation
 		entropy, _ := bip39.EntropyFromMnemonic(ms)
-		mnemonic, _ := bip39.NewMnemonic(entropy)
-		ks := &wallet.KeyStore{
-			Entropy:  entropy,
-			Seed:     bip39.NewSeed(mnemonic, ""),
-			Mnemonic: mnemonic,
+		ks, err := newKeyStoreFromEntropy(entropy)
+		if err != nil {
+			return err
 		}
-		_, kp, _ := ks.DeriveForIndexPath(0)
-		ks.BaseAddress = kp.Address
 
 		name := ks.BaseAddress.String()
 		if cCtx.NArg() == 3 {
@@ -82,15 +138,10 @@ var znnCliWalletCreateFromMnemonic = &cli.Command{
 		}
 
 		password := cCtx.Args().Get(1)
-		kf, _ := ks.Encrypt(password)
-		kf.Path = filepath.Join(walletDir, name)
-		//kf.Write()
-		// Uncomment when file mode is fixed
-		keyFileJson, err := json.MarshalIndent(kf, "", "    ")
-		if err != nil {
+		if _, err := writeKeyStore(ks, password, walletDir, name); err != nil {
+			fmt.Println("Error writing keyStore:", err)
 			return err
 		}
-		os.WriteFile(kf.Path, keyFileJson, 0600)
 
 		fmt.Println("keyStore successfully created:", name)
 		return nil
@@ -123,3 +174,437 @@ var znnCliWalletList = &cli.Command{
 		return nil
 	},
 }
+
+var znnCliWalletBackupSplit = &cli.Command{
+	Name:  "wallet.backup.split",
+	Usage: "--threshold k --shares n",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:     "threshold",
+			Usage:    "Number of shares required to recover the keyStore",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:     "shares",
+			Usage:    "Number of shares to create",
+			Required: true,
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 0 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("wallet.backup.split --threshold k --shares n")
+			return nil
+		}
+		threshold := cCtx.Int("threshold")
+		n := cCtx.Int("shares")
+		if threshold < 2 || threshold > n || n > 255 {
+			fmt.Println("Error! The threshold must be at least 2 and at most the number of shares (max 255)")
+			return nil
+		}
+
+		ks, err := getZnnCliKeyStore(walletDir, cCtx)
+		if err != nil {
+			fmt.Println("Error getting keyStore:", err)
+			return err
+		}
+
+		shares, err := shamirSplit(ks.Entropy, threshold, n)
+		if err != nil {
+			fmt.Println("Error splitting keyStore:", err)
+			return err
+		}
+
+		fmt.Printf("Backup of %s split into %d shares, any %d of them recover the keyStore\n", ks.BaseAddress, n, threshold)
+		for _, s := range shares {
+			fmt.Printf("Share %d/%d:\n", s.index, n)
+			fmt.Println(s.words())
+		}
+		return nil
+	},
+}
+
+var znnCliWalletBackupVerify = &cli.Command{
+	Name:  "wallet.backup.verify",
+	Usage: "\"share\"",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("wallet.backup.verify \"share\"")
+			return nil
+		}
+
+		share, err := parseShamirShare(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error! Invalid share:", err)
+			return err
+		}
+		fmt.Printf("Share %d of backup %04x is valid, %d shares are required for recovery\n", share.index, share.id, share.threshold)
+		return nil
+	},
+}
+
+var znnCliWalletBackupCombine = &cli.Command{
+	Name:  "wallet.backup.combine",
+	Usage: "--share \"share\" ... passphrase [keyStoreName]",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "share",
+			Usage:    "A share created by wallet.backup.split, repeat for every share",
+			Required: true,
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if !(cCtx.NArg() == 1 || cCtx.NArg() == 2) {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("wallet.backup.combine --share \"share\" ... passphrase [keyStoreName]")
+			return nil
+		}
+
+		var shares []*shamirShare
+		for i, s := range cCtx.StringSlice("share") {
+			share, err := parseShamirShare(s)
+			if err != nil {
+				fmt.Printf("Error! Invalid share #%d: %v\n", i+1, err)
+				return err
+			}
+			shares = append(shares, share)
+		}
+
+		entropy, err := shamirCombine(shares)
+		if err != nil {
+			fmt.Println("Error combining shares:", err)
+			return err
+		}
+		ks, err := newKeyStoreFromEntropy(entropy)
+		if err != nil {
+			return err
+		}
+
+		name := ks.BaseAddress.String()
+		if cCtx.NArg() == 2 {
+			name = cCtx.Args().Get(1)
+		}
+		if _, err := createKeyStore(ks, cCtx.Args().Get(0), walletDir, name); err != nil {
+			fmt.Println("Error writing keyStore:", err)
+			return err
+		}
+
+		fmt.Println("keyStore successfully recovered:", name)
+		fmt.Println("Base address", ks.BaseAddress)
+		return nil
+	},
+}
+
+const walletBundleType = "nomctl-wallet-bundle"
+
+type walletBundleEntry struct {
+	Name        string          `json:"name"`
+	BaseAddress types.Address   `json:"baseAddress"`
+	Label       string          `json:"label,omitempty"`
+	KeyFile     json.RawMessage `json:"keyFile"`
+}
+
+type walletBundle struct {
+	Created int64               `json:"created"`
+	Entries []walletBundleEntry `json:"entries"`
+}
+
+func isValidKeyStoreName(name string) bool {
+	return name != "" && name != "." && name != ".." && filepath.Base(name) == name
+}
+
+// parseKeyFile validates the keyStore format shared by znnd, nomctl and the
+// Dart/Flutter wallets without decrypting it
+func parseKeyFile(data []byte) (*wallet.KeyFile, error) {
+	kf := new(wallet.KeyFile)
+	if err := json.Unmarshal(data, kf); err != nil {
+		return nil, err
+	}
+	if kf.Version != 1 {
+		return nil, wallet.ErrKeyFileInvalidVersion
+	}
+	if kf.Crypto.CipherName != "aes-256-gcm" {
+		return nil, wallet.ErrKeyFileInvalidCipher
+	}
+	if kf.Crypto.KDF != "argon2.IDKey" {
+		return nil, wallet.ErrKeyFileInvalidKDF
+	}
+	if kf.BaseAddress == types.ZeroAddress || len(kf.Crypto.CipherData) == 0 {
+		return nil, errors.New("keyStore is missing its base address or cipher data")
+	}
+	return kf, nil
+}
+
+// keyStoresByAddress maps the base address of every keyStore in dir to its name
+func keyStoresByAddress(dir string) (map[types.Address]string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[types.Address]string)
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		kf, err := wallet.ReadKeyFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			continue
+		}
+		result[kf.BaseAddress] = f.Name()
+	}
+	return result, nil
+}
+
+// planKeyFileImport picks the name a validated keyStore is imported as without
+// writing it. It returns an empty name if the keyStore already exists. taken
+// holds the names planned for other keyStores of the same import.
+func planKeyFileImport(name string, kf *wallet.KeyFile, existing map[types.Address]string, taken map[string]bool, rename bool) (string, error) {
+	if !isValidKeyStoreName(name) {
+		return "", fmt.Errorf("invalid keyStore name %q", name)
+	}
+	if other, ok := existing[kf.BaseAddress]; ok {
+		fmt.Printf("Skipping %s: %s is already available as keyStore %s\n", name, kf.BaseAddress, other)
+		return "", nil
+	}
+
+	target := name
+	for i := 1; ; i++ {
+		_, err := os.Stat(filepath.Join(walletDir, target))
+		if os.IsNotExist(err) && !taken[target] {
+			break
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if !rename {
+			return "", fmt.Errorf("keyStore %s already exists with a different address, use --rename to import it under a new name", name)
+		}
+		target = fmt.Sprintf("%s-%d", name, i)
+	}
+	existing[kf.BaseAddress] = target
+	taken[target] = true
+	return target, nil
+}
+
+// importKeyFile writes a validated keyStore to the wallet directory. It returns
+// the name it was written as, or an empty name if the keyStore already exists.
+func importKeyFile(name string, data []byte, kf *wallet.KeyFile, existing map[types.Address]string, rename bool) (string, error) {
+	target, err := planKeyFileImport(name, kf, existing, make(map[string]bool), rename)
+	if err != nil || target == "" {
+		return "", err
+	}
+	return target, writeFileExclusive(filepath.Join(walletDir, target), data, 0600)
+}
+
+var znnCliWalletExport = &cli.Command{
+	Name:  "wallet.export",
+	Usage: "passphrase file [keyStoreName ...]",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() < 2 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("wallet.export passphrase file [keyStoreName ...]")
+			return nil
+		}
+		passphrase := cCtx.Args().Get(0)
+		out := cCtx.Args().Get(1)
+
+		names := cCtx.Args().Slice()[2:]
+		if len(names) == 0 {
+			files, err := os.ReadDir(walletDir)
+			if err != nil {
+				return err
+			}
+			for _, f := range files {
+				if !f.IsDir() {
+					names = append(names, f.Name())
+				}
+			}
+		}
+		if len(names) == 0 {
+			fmt.Println("No keyStores found")
+			return nil
+		}
+
+		book, err := readAddressBook()
+		if err != nil {
+			fmt.Println("Error reading address book:", err)
+			return err
+		}
+
+		bundle := walletBundle{Created: time.Now().Unix()}
+		for _, name := range names {
+			if !isValidKeyStoreName(name) {
+				fmt.Println("Error! Invalid keyStore name", name)
+				return nil
+			}
+			data, err := os.ReadFile(filepath.Join(walletDir, name))
+			if err != nil {
+				fmt.Println("Error reading keyStore:", err)
+				return err
+			}
+			kf, err := parseKeyFile(data)
+			if err != nil {
+				fmt.Printf("Error! %s is not a valid keyStore: %v\n", name, err)
+				return err
+			}
+			bundle.Entries = append(bundle.Entries, walletBundleEntry{
+				Name:        name,
+				BaseAddress: kf.BaseAddress,
+				Label:       book.labelFor(kf.BaseAddress),
+				KeyFile:     data,
+			})
+		}
+
+		plain, err := json.Marshal(bundle)
+		if err != nil {
+			return err
+		}
+		encrypted, err := encryptWithPassphrase(walletBundleType, plain, passphrase)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(encrypted, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := writeFileExclusive(out, data, 0600); err != nil {
+			fmt.Println("Error writing bundle:", err)
+			return err
+		}
+
+		fmt.Printf("Exported %d keyStore(s) to %s\n", len(bundle.Entries), out)
+		for _, e := range bundle.Entries {
+			fmt.Println(" ", e.Name, e.BaseAddress)
+		}
+		return nil
+	},
+}
+
+var znnCliWalletImport = &cli.Command{
+	Name:  "wallet.import",
+	Usage: "file [passphrase]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "rename",
+			Usage: "Import keyStores whose name is already taken under a new name",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if !(cCtx.NArg() == 1 || cCtx.NArg() == 2) {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("wallet.import keyStoreFile")
+			fmt.Println("wallet.import bundleFile passphrase")
+			return nil
+		}
+		file := cCtx.Args().Get(0)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Println("Error reading file:", err)
+			return err
+		}
+
+		existing, err := keyStoresByAddress(walletDir)
+		if err != nil {
+			return err
+		}
+
+		// A single keyStore written by znnd, nomctl or the Dart/Flutter wallets
+		if kf, err := parseKeyFile(data); err == nil {
+			name, err := importKeyFile(filepath.Base(file), data, kf, existing, cCtx.Bool("rename"))
+			if err != nil {
+				fmt.Println("Error importing keyStore:", err)
+				return err
+			}
+			if name != "" {
+				fmt.Println("keyStore successfully imported:", name)
+			}
+			return nil
+		}
+
+		encrypted := new(encryptedData)
+		if err := json.Unmarshal(data, encrypted); err != nil || encrypted.Type != walletBundleType {
+			err := fmt.Errorf("%s is neither a keyStore nor a wallet bundle", file)
+			fmt.Println("Error!", err)
+			return err
+		}
+		if cCtx.NArg() != 2 {
+			fmt.Println("Error! A passphrase is required to import a wallet bundle")
+			return nil
+		}
+		plain, err := encrypted.decrypt(cCtx.Args().Get(1))
+		if err != nil {
+			fmt.Println("Error decrypting bundle:", err)
+			return err
+		}
+		bundle := new(walletBundle)
+		if err := json.Unmarshal(plain, bundle); err != nil {
+			fmt.Println("Error parsing bundle:", err)
+			return err
+		}
+
+		book, err := readAddressBook()
+		if err != nil {
+			fmt.Println("Error reading address book:", err)
+			return err
+		}
+
+		// Validate everything and pick the names before writing anything
+		targets := make([]string, len(bundle.Entries))
+		taken := make(map[string]bool)
+		for i, e := range bundle.Entries {
+			kf, err := parseKeyFile(e.KeyFile)
+			if err != nil {
+				fmt.Printf("Error! Bundle entry %s is not a valid keyStore: %v\n", e.Name, err)
+				return err
+			}
+			if kf.BaseAddress != e.BaseAddress {
+				err := fmt.Errorf("bundle entry %s does not match its base address %s", e.Name, e.BaseAddress)
+				fmt.Println("Error!", err)
+				return err
+			}
+			if e.Label != "" {
+				if err := validateLabel(e.Label); err != nil {
+					fmt.Printf("Error! Bundle entry %s has an invalid label: %v\n", e.Name, err)
+					return err
+				}
+				if other, ok := book[e.Label]; ok && other != e.BaseAddress {
+					err := fmt.Errorf("label @%s of %s is already used for %s", e.Label, e.BaseAddress, other)
+					fmt.Println("Error!", err)
+					return err
+				}
+				book[e.Label] = e.BaseAddress
+			}
+			if targets[i], err = planKeyFileImport(e.Name, kf, existing, taken, cCtx.Bool("rename")); err != nil {
+				fmt.Printf("Error importing %s: %v\n", e.Name, err)
+				return err
+			}
+		}
+
+		// Remove what was written if a write fails so that nothing is imported
+		var written []string
+		for i, e := range bundle.Entries {
+			if targets[i] == "" {
+				continue
+			}
+			path := filepath.Join(walletDir, targets[i])
+			if err := writeFileExclusive(path, e.KeyFile, 0600); err != nil {
+				for _, w := range written {
+					_ = os.Remove(w)
+				}
+				fmt.Printf("Error importing %s: %v\n", e.Name, err)
+				return err
+			}
+			written = append(written, path)
+			fmt.Println("keyStore successfully imported:", targets[i], e.BaseAddress)
+		}
+		if err := writeAddressBook(book); err != nil {
+			fmt.Println("Error writing address book:", err)
+			return err
+		}
+
+		imported := len(written)
+		fmt.Printf("Imported %d of %d keyStore(s)\n", imported, len(bundle.Entries))
+		return nil
+	},
+}